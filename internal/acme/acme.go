@@ -0,0 +1,398 @@
+// Package acme issues and renews TLS certificates from an ACME
+// certificate authority (Let's Encrypt, ZeroSSL, Google Trust Services,
+// or any other RFC 8555 server), replacing the acme.sh shell-out
+// GhostGate used to depend on. It answers HTTP-01 challenges over
+// GhostGate's own :80 listener and DNS-01 challenges via the providers
+// in the dns01 subpackage, and writes every issued certificate straight
+// into a certs.Store so it is served over SNI and reported by the admin
+// API without a restart.
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+
+	"github.com/ghostkellz/ghostgate/internal/acme/dns01"
+	"github.com/ghostkellz/ghostgate/internal/certs"
+	"github.com/ghostkellz/ghostgate/internal/config"
+)
+
+// LetsEncryptURL is the ACME directory GhostGate issues against when a
+// domain doesn't set acme.directory_url.
+const LetsEncryptURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+// RenewBefore is how long before a certificate's expiry Manager renews
+// it, matching acme.sh's default and Let's Encrypt's own guidance.
+const RenewBefore = 30 * 24 * time.Hour
+
+// checkInterval is how often the background renewal loop re-checks
+// every managed domain's expiry.
+const checkInterval = 12 * time.Hour
+
+// dnsPropagationWait is how long Manager gives a DNS-01 provider's TXT
+// record to propagate before asking the CA to validate it.
+const dnsPropagationWait = 30 * time.Second
+
+// Manager issues and renews certificates for domains with Autocert
+// enabled, installing them into a certs.Store.
+type Manager struct {
+	store *certs.Store
+
+	mu       sync.Mutex
+	accounts map[string]*acme.Client // directory URL -> registered account client
+
+	challenges sync.Map // HTTP-01 token -> key authorization
+}
+
+// NewManager builds a Manager that installs issued certificates into
+// store.
+func NewManager(store *certs.Store) *Manager {
+	return &Manager{store: store, accounts: make(map[string]*acme.Client)}
+}
+
+// HTTPHandler answers ACME HTTP-01 challenges under
+// /.well-known/acme-challenge/. Mount it on GhostGate's plain-HTTP
+// listener ahead of any HTTPS redirect.
+func (m *Manager) HTTPHandler() http.Handler {
+	return http.StripPrefix("/.well-known/acme-challenge/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyAuth, ok := m.challenges.Load(r.URL.Path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, keyAuth)
+	}))
+}
+
+// Start runs a background loop that re-checks every Autocert domain
+// domainsFn returns every checkInterval, renewing any certificate within
+// RenewBefore of expiry. It returns immediately; the loop stops when ctx
+// is cancelled.
+func (m *Manager) Start(ctx context.Context, domainsFn func() []config.DomainConfig) {
+	go func() {
+		m.renewAll(ctx, domainsFn())
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.renewAll(ctx, domainsFn())
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (m *Manager) renewAll(ctx context.Context, domains []config.DomainConfig) {
+	for _, d := range domains {
+		if !d.Autocert {
+			continue
+		}
+		if err := m.EnsureCert(ctx, d, false); err != nil {
+			log.Printf("[WARN] acme: %s: %v", d.Domain, err)
+		}
+	}
+}
+
+// EnsureCert issues a certificate for d.Domain if the store has none, or
+// renews it if it is within RenewBefore of expiry. force skips the
+// expiry check, for the admin API's manual "renew now" endpoint.
+func (m *Manager) EnsureCert(ctx context.Context, d config.DomainConfig, force bool) error {
+	if !force {
+		if exp := m.store.ExpiresAt(d.Domain); !exp.IsZero() && time.Until(exp) > RenewBefore {
+			return nil
+		}
+	}
+
+	client, err := m.clientFor(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	return withBackoff(ctx, d.Domain, func() error {
+		return m.issue(ctx, client, d)
+	})
+}
+
+// clientFor returns the registered ACME client for d's directory URL,
+// registering (and, if configured, EAB-binding) a new account key the
+// first time that directory is used.
+func (m *Manager) clientFor(ctx context.Context, d config.DomainConfig) (*acme.Client, error) {
+	dirURL := d.ACME.DirectoryURL
+	if dirURL == "" {
+		dirURL = LetsEncryptURL
+	}
+
+	m.mu.Lock()
+	if client, ok := m.accounts[dirURL]; ok {
+		m.mu.Unlock()
+		return client, nil
+	}
+	m.mu.Unlock()
+
+	accountKey, err := m.loadOrGenerateAccountKey(dirURL)
+	if err != nil {
+		return nil, err
+	}
+	client := &acme.Client{Key: accountKey, DirectoryURL: dirURL}
+
+	account := &acme.Account{}
+	if d.ACMEEmail != "" {
+		account.Contact = []string{"mailto:" + d.ACMEEmail}
+	}
+	if d.ACME.EABKeyID != "" {
+		eabKey, err := base64.RawURLEncoding.DecodeString(d.ACME.EABHMACKey)
+		if err != nil {
+			return nil, fmt.Errorf("acme: decoding eab_hmac_key: %w", err)
+		}
+		account.ExternalAccountBinding = &acme.ExternalAccountBinding{KID: d.ACME.EABKeyID, Key: eabKey}
+	}
+
+	// Registration is a network round-trip, so it runs without m.mu held;
+	// two domains racing to register the same new directory URL both
+	// register (CAs treat re-registering an already-known key as success),
+	// and whichever stores into m.accounts first wins; the later one
+	// returns the stored client instead.
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil {
+		return nil, fmt.Errorf("acme: registering account with %s: %w", dirURL, err)
+	}
+
+	m.mu.Lock()
+	if existing, ok := m.accounts[dirURL]; ok {
+		m.mu.Unlock()
+		return existing, nil
+	}
+	m.accounts[dirURL] = client
+	m.mu.Unlock()
+	return client, nil
+}
+
+// loadOrGenerateAccountKey returns the ACME account key previously
+// persisted into m.store for dirURL, or generates and persists a fresh
+// one the first time dirURL is used. Reusing the same key across
+// restarts means client.Register below re-associates with GhostGate's
+// existing account instead of creating a new one every time the process
+// starts.
+func (m *Manager) loadOrGenerateAccountKey(dirURL string) (*ecdsa.PrivateKey, error) {
+	if keyDER, err := m.store.LoadACMEAccountKey(dirURL); err == nil {
+		key, err := x509.ParseECPrivateKey(keyDER)
+		if err != nil {
+			return nil, fmt.Errorf("acme: parsing stored account key for %s: %w", dirURL, err)
+		}
+		return key, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("acme: loading stored account key for %s: %w", dirURL, err)
+	}
+
+	accountKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("acme: generating account key: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("acme: encoding account key: %w", err)
+	}
+	if err := m.store.SaveACMEAccountKey(dirURL, keyDER); err != nil {
+		return nil, fmt.Errorf("acme: persisting account key for %s: %w", dirURL, err)
+	}
+	return accountKey, nil
+}
+
+// issue runs one full order-authorize-finalize cycle for d.Domain and
+// installs the resulting certificate into m.store.
+func (m *Manager) issue(ctx context.Context, client *acme.Client, d config.DomainConfig) error {
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(d.Domain))
+	if err != nil {
+		return fmt.Errorf("acme: creating order for %s: %w", d.Domain, err)
+	}
+
+	for _, authzURL := range order.AuthzURLs {
+		authz, err := client.GetAuthorization(ctx, authzURL)
+		if err != nil {
+			return fmt.Errorf("acme: fetching authorization for %s: %w", d.Domain, err)
+		}
+		if authz.Status == acme.StatusValid {
+			continue
+		}
+		cleanup, err := m.satisfy(ctx, client, d, authz)
+		if err != nil {
+			return err
+		}
+		_, waitErr := client.WaitAuthorization(ctx, authzURL)
+		if cleanup != nil {
+			cleanup()
+		}
+		if waitErr != nil {
+			return fmt.Errorf("acme: waiting on authorization for %s: %w", d.Domain, waitErr)
+		}
+	}
+
+	order, err = client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return fmt.Errorf("acme: waiting on order for %s: %w", d.Domain, err)
+	}
+
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("acme: generating certificate key for %s: %w", d.Domain, err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: d.Domain},
+		DNSNames: []string{d.Domain},
+	}, certKey)
+	if err != nil {
+		return fmt.Errorf("acme: creating CSR for %s: %w", d.Domain, err)
+	}
+
+	der, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return fmt.Errorf("acme: finalizing order for %s: %w", d.Domain, err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(certKey)
+	if err != nil {
+		return fmt.Errorf("acme: encoding private key for %s: %w", d.Domain, err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	var certPEM []byte
+	for _, block := range der {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block})...)
+	}
+
+	if err := m.store.Install(d.Domain, certPEM, keyPEM); err != nil {
+		return fmt.Errorf("acme: installing certificate for %s: %w", d.Domain, err)
+	}
+
+	log.Printf("[INFO] acme: issued certificate for %s", d.Domain)
+	return nil
+}
+
+// satisfy picks the challenge matching d's configured method and drives
+// it up through client.Accept: serving it over HTTP-01, or publishing a
+// TXT record via the configured dns01 provider for DNS-01. client.Accept
+// only tells the CA to begin validating; it returns before the CA
+// actually fetches the token or looks up the TXT record. So satisfy
+// returns a cleanup func instead of tearing the challenge response down
+// itself — the caller must run it only after client.WaitAuthorization
+// confirms the CA is done validating.
+func (m *Manager) satisfy(ctx context.Context, client *acme.Client, d config.DomainConfig, authz *acme.Authorization) (cleanup func(), err error) {
+	wantType := "http-01"
+	if d.ACME.Challenge == "dns-01" {
+		wantType = "dns-01"
+	}
+
+	var challenge *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == wantType {
+			challenge = c
+			break
+		}
+	}
+	if challenge == nil {
+		return nil, fmt.Errorf("acme: no %s challenge offered for %s", wantType, d.Domain)
+	}
+
+	switch wantType {
+	case "http-01":
+		keyAuth, err := client.HTTP01ChallengeResponse(challenge.Token)
+		if err != nil {
+			return nil, fmt.Errorf("acme: building HTTP-01 response for %s: %w", d.Domain, err)
+		}
+		m.challenges.Store(challenge.Token, keyAuth)
+		cleanup = func() { m.challenges.Delete(challenge.Token) }
+
+	case "dns-01":
+		if d.ACME.DNSProvider == "" {
+			return nil, fmt.Errorf("acme: domain %s requests dns-01 but sets no acme.dns_provider", d.Domain)
+		}
+		provider, err := dns01.New(d.ACME.DNSProvider, d.ACME.DNSProviderConfig)
+		if err != nil {
+			return nil, fmt.Errorf("acme: %w", err)
+		}
+		value, err := client.DNS01ChallengeRecord(challenge.Token)
+		if err != nil {
+			return nil, fmt.Errorf("acme: building DNS-01 record for %s: %w", d.Domain, err)
+		}
+		if err := provider.Present(ctx, d.Domain, value); err != nil {
+			return nil, fmt.Errorf("acme: %w", err)
+		}
+		cleanup = func() {
+			if err := provider.CleanUp(ctx, d.Domain, value); err != nil {
+				log.Printf("[WARN] acme: cleaning up DNS-01 record for %s: %v", d.Domain, err)
+			}
+		}
+
+		select {
+		case <-time.After(dnsPropagationWait):
+		case <-ctx.Done():
+			cleanup()
+			return nil, ctx.Err()
+		}
+	}
+
+	if _, err := client.Accept(ctx, challenge); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("acme: accepting %s challenge for %s: %w", wantType, d.Domain, err)
+	}
+	return cleanup, nil
+}
+
+// withBackoff retries fn with exponential backoff when the CA reports
+// it's rate limiting GhostGate, honoring the CA's own pacing instead of
+// hammering it.
+func withBackoff(ctx context.Context, domain string, fn func() error) error {
+	const maxAttempts = 5
+	const maxBackoff = 2 * time.Minute
+
+	backoff := time.Second
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !isRateLimited(err) {
+			return err
+		}
+
+		log.Printf("[WARN] acme: %s rate-limited by CA (attempt %d/%d), backing off %s: %v", domain, attempt, maxAttempts, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	return fmt.Errorf("acme: giving up on %s after %d attempts: %w", domain, maxAttempts, lastErr)
+}
+
+// isRateLimited reports whether err is an ACME "rateLimited" problem.
+func isRateLimited(err error) bool {
+	var acmeErr *acme.Error
+	if errors.As(err, &acmeErr) {
+		return acmeErr.ProblemType == "urn:ietf:params:acme:error:rateLimited"
+	}
+	return false
+}
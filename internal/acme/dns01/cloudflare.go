@@ -0,0 +1,109 @@
+package dns01
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// cloudflareProvider satisfies DNS-01 challenges via the Cloudflare v4
+// REST API using an API token, without the cloudflare-go SDK.
+type cloudflareProvider struct {
+	apiToken string
+	zoneID   string
+	client   *http.Client
+}
+
+// newCloudflareProvider builds a Provider from
+// {"api_token": "...", "zone_id": "..."}.
+func newCloudflareProvider(cfg map[string]string) (Provider, error) {
+	token, zone := cfg["api_token"], cfg["zone_id"]
+	if token == "" || zone == "" {
+		return nil, fmt.Errorf("dns01/cloudflare: api_token and zone_id are required")
+	}
+	return &cloudflareProvider{
+		apiToken: token,
+		zoneID:   zone,
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+type cfDNSRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+type cfListResponse struct {
+	Result []cfDNSRecord `json:"result"`
+}
+
+func (p *cloudflareProvider) do(ctx context.Context, method, url string, body interface{}) (*http.Response, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+	return p.client.Do(req)
+}
+
+// Present creates the "_acme-challenge.<domain>" TXT record.
+func (p *cloudflareProvider) Present(ctx context.Context, domain, value string) error {
+	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records", p.zoneID)
+	record := cfDNSRecord{Type: "TXT", Name: "_acme-challenge." + domain, Content: value, TTL: 120}
+
+	resp, err := p.do(ctx, http.MethodPost, url, record)
+	if err != nil {
+		return fmt.Errorf("dns01/cloudflare: creating TXT record for %s: %w", domain, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("dns01/cloudflare: creating TXT record for %s: unexpected status %s", domain, resp.Status)
+	}
+	return nil
+}
+
+// CleanUp removes every "_acme-challenge.<domain>" TXT record Present
+// created. Cloudflare has no delete-by-name-and-content endpoint, so the
+// record is looked up first.
+func (p *cloudflareProvider) CleanUp(ctx context.Context, domain, value string) error {
+	name := "_acme-challenge." + domain
+	listURL := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records?type=TXT&name=%s", p.zoneID, name)
+
+	resp, err := p.do(ctx, http.MethodGet, listURL, nil)
+	if err != nil {
+		return fmt.Errorf("dns01/cloudflare: looking up TXT record for %s: %w", domain, err)
+	}
+	defer resp.Body.Close()
+
+	var list cfListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return fmt.Errorf("dns01/cloudflare: decoding TXT record lookup for %s: %w", domain, err)
+	}
+
+	for _, record := range list.Result {
+		delURL := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records/%s", p.zoneID, record.ID)
+		delResp, err := p.do(ctx, http.MethodDelete, delURL, nil)
+		if err != nil {
+			return fmt.Errorf("dns01/cloudflare: deleting TXT record %s: %w", record.ID, err)
+		}
+		delResp.Body.Close()
+	}
+	return nil
+}
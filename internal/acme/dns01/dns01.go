@@ -0,0 +1,42 @@
+// Package dns01 defines the provider interface GhostGate's ACME manager
+// (internal/acme) uses to satisfy DNS-01 challenges, and a provider per
+// DNS host: Cloudflare, Route53, PowerDNS, and RFC2136 (BIND and
+// compatible nameservers). Every provider speaks its backend's API
+// directly, matching the no-SDK-dependency convention GhostGate's
+// internal/provider implementations already use.
+package dns01
+
+import (
+	"context"
+	"fmt"
+)
+
+// Provider creates and removes the "_acme-challenge.<domain>." TXT
+// record an ACME CA queries to validate a DNS-01 challenge.
+type Provider interface {
+	// Present publishes value as a TXT record at
+	// "_acme-challenge.<domain>.". value is the base64url-encoded SHA-256
+	// digest golang.org/x/crypto/acme computes for the challenge; the
+	// provider just needs to get it into DNS.
+	Present(ctx context.Context, domain, value string) error
+	// CleanUp removes the record Present created.
+	CleanUp(ctx context.Context, domain, value string) error
+}
+
+// New builds the named provider ("cloudflare", "route53", "powerdns", or
+// "rfc2136") from cfg, as read from a domain's
+// acme.dns_provider_config in YAML.
+func New(name string, cfg map[string]string) (Provider, error) {
+	switch name {
+	case "cloudflare":
+		return newCloudflareProvider(cfg)
+	case "route53":
+		return newRoute53Provider(cfg)
+	case "powerdns":
+		return newPowerDNSProvider(cfg)
+	case "rfc2136":
+		return newRFC2136Provider(cfg)
+	default:
+		return nil, fmt.Errorf("dns01: unknown provider %q", name)
+	}
+}
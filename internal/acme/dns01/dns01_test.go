@@ -0,0 +1,75 @@
+package dns01
+
+import "testing"
+
+func TestNewUnknownProvider(t *testing.T) {
+	if _, err := New("unknown", nil); err == nil {
+		t.Fatal("expected an error for an unknown provider name")
+	}
+}
+
+func TestNewRoutesToEachProvider(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  map[string]string
+	}{
+		{"cloudflare", map[string]string{"api_token": "t", "zone_id": "z"}},
+		{"route53", map[string]string{"access_key_id": "a", "secret_access_key": "s", "hosted_zone_id": "z"}},
+		{"powerdns", map[string]string{"api_url": "http://localhost:8081", "api_key": "k", "zone": "example.com."}},
+		{"rfc2136", map[string]string{"nameserver": "127.0.0.1", "zone": "example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.name, tt.cfg)
+			if err != nil {
+				t.Fatalf("New(%q, %v): %v", tt.name, tt.cfg, err)
+			}
+			if p == nil {
+				t.Fatalf("New(%q, %v) returned a nil Provider with no error", tt.name, tt.cfg)
+			}
+		})
+	}
+}
+
+func TestProviderConfigValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  map[string]string
+	}{
+		{"cloudflare", nil},
+		{"cloudflare", map[string]string{"api_token": "t"}},
+		{"route53", map[string]string{"access_key_id": "a"}},
+		{"powerdns", map[string]string{"api_key": "k"}},
+		{"rfc2136", map[string]string{"nameserver": "127.0.0.1"}},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.name, tt.cfg); err == nil {
+			t.Errorf("New(%q, %v) = nil error, want an error for missing required config", tt.name, tt.cfg)
+		}
+	}
+}
+
+func TestRFC2136DefaultsToPort53(t *testing.T) {
+	p, err := New("rfc2136", map[string]string{"nameserver": "127.0.0.1", "zone": "example.com"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	r := p.(*rfc2136Provider)
+	if r.nameserver != "127.0.0.1:53" {
+		t.Errorf("nameserver = %q, want %q", r.nameserver, "127.0.0.1:53")
+	}
+	if r.zone != "example.com." {
+		t.Errorf("zone = %q, want trailing-dot %q", r.zone, "example.com.")
+	}
+}
+
+func TestRFC2136PreservesExplicitPort(t *testing.T) {
+	p, err := New("rfc2136", map[string]string{"nameserver": "127.0.0.1:9953", "zone": "example.com."})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	r := p.(*rfc2136Provider)
+	if r.nameserver != "127.0.0.1:9953" {
+		t.Errorf("nameserver = %q, want %q", r.nameserver, "127.0.0.1:9953")
+	}
+}
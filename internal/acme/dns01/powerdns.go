@@ -0,0 +1,119 @@
+package dns01
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// powerDNSProvider satisfies DNS-01 challenges via the PowerDNS
+// Authoritative Server HTTP API.
+type powerDNSProvider struct {
+	apiURL string // e.g. "http://127.0.0.1:8081"
+	apiKey string
+	server string // PowerDNS server ID, usually "localhost"
+	zone   string // trailing-dot zone name, e.g. "example.com."
+	client *http.Client
+}
+
+// newPowerDNSProvider builds a Provider from
+// {"api_url": "...", "api_key": "...", "server": "...", "zone": "..."}.
+// server defaults to "localhost" if empty.
+func newPowerDNSProvider(cfg map[string]string) (Provider, error) {
+	apiURL, apiKey, zone := cfg["api_url"], cfg["api_key"], cfg["zone"]
+	if apiURL == "" || apiKey == "" || zone == "" {
+		return nil, fmt.Errorf("dns01/powerdns: api_url, api_key, and zone are required")
+	}
+	server := cfg["server"]
+	if server == "" {
+		server = "localhost"
+	}
+	return &powerDNSProvider{
+		apiURL: strings.TrimRight(apiURL, "/"),
+		apiKey: apiKey,
+		server: server,
+		zone:   ensureTrailingDot(zone),
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func ensureTrailingDot(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+type pdnsPatchRequest struct {
+	RRSets []pdnsRRSet `json:"rrsets"`
+}
+
+type pdnsRRSet struct {
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	TTL        int           `json:"ttl"`
+	ChangeType string        `json:"changetype"`
+	Records    []pdnsRecord  `json:"records,omitempty"`
+	Comments   []interface{} `json:"comments,omitempty"`
+}
+
+type pdnsRecord struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+func (p *powerDNSProvider) patch(ctx context.Context, rrset pdnsRRSet) error {
+	url := fmt.Sprintf("%s/api/v1/servers/%s/zones/%s", p.apiURL, p.server, p.zone)
+	body, err := json.Marshal(pdnsPatchRequest{RRSets: []pdnsRRSet{rrset}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-API-Key", p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Present creates the "_acme-challenge.<domain>" TXT record via a PATCH
+// with changetype REPLACE.
+func (p *powerDNSProvider) Present(ctx context.Context, domain, value string) error {
+	name := ensureTrailingDot("_acme-challenge." + domain)
+	rrset := pdnsRRSet{
+		Name:       name,
+		Type:       "TXT",
+		TTL:        120,
+		ChangeType: "REPLACE",
+		Records:    []pdnsRecord{{Content: `"` + value + `"`}},
+	}
+	if err := p.patch(ctx, rrset); err != nil {
+		return fmt.Errorf("dns01/powerdns: creating TXT record for %s: %w", domain, err)
+	}
+	return nil
+}
+
+// CleanUp removes the "_acme-challenge.<domain>" TXT record via a PATCH
+// with changetype DELETE.
+func (p *powerDNSProvider) CleanUp(ctx context.Context, domain, value string) error {
+	name := ensureTrailingDot("_acme-challenge." + domain)
+	rrset := pdnsRRSet{Name: name, Type: "TXT", ChangeType: "DELETE"}
+	if err := p.patch(ctx, rrset); err != nil {
+		return fmt.Errorf("dns01/powerdns: removing TXT record for %s: %w", domain, err)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package dns01
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+// rfc2136Provider satisfies DNS-01 challenges by sending a signed RFC
+// 2136 DNS UPDATE directly to an authoritative nameserver (BIND, Knot,
+// PowerDNS in primary mode, ...), with no external dig/nsupdate binary.
+type rfc2136Provider struct {
+	nameserver string // "host:port"
+	zone       string // trailing-dot zone name
+
+	tsigName   string // trailing-dot key name; empty disables TSIG
+	tsigSecret []byte
+	tsigAlgo   string // trailing-dot algorithm name, e.g. "hmac-sha256."
+}
+
+// newRFC2136Provider builds a Provider from {"nameserver": "...",
+// "zone": "...", "tsig_key": "...", "tsig_secret": "...",
+// "tsig_algorithm": "..."}. tsig_secret is base64-encoded; TSIG signing
+// is skipped if tsig_key is empty. tsig_algorithm defaults to
+// "hmac-sha256." and also accepts "hmac-sha1.".
+func newRFC2136Provider(cfg map[string]string) (Provider, error) {
+	nameserver, zone := cfg["nameserver"], cfg["zone"]
+	if nameserver == "" || zone == "" {
+		return nil, fmt.Errorf("dns01/rfc2136: nameserver and zone are required")
+	}
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		nameserver = net.JoinHostPort(nameserver, "53")
+	}
+
+	p := &rfc2136Provider{nameserver: nameserver, zone: ensureTrailingDot(zone)}
+
+	if key := cfg["tsig_key"]; key != "" {
+		secret, err := base64.StdEncoding.DecodeString(cfg["tsig_secret"])
+		if err != nil {
+			return nil, fmt.Errorf("dns01/rfc2136: decoding tsig_secret: %w", err)
+		}
+		p.tsigName = ensureTrailingDot(key)
+		p.tsigSecret = secret
+		p.tsigAlgo = ensureTrailingDot(cfg["tsig_algorithm"])
+		if p.tsigAlgo == "." {
+			p.tsigAlgo = "hmac-sha256."
+		}
+	}
+
+	return p, nil
+}
+
+// Present adds the "_acme-challenge.<domain>" TXT record via UPDATE.
+func (p *rfc2136Provider) Present(ctx context.Context, domain, value string) error {
+	return p.update(ctx, domain, value, true)
+}
+
+// CleanUp removes the TXT record Present added.
+func (p *rfc2136Provider) CleanUp(ctx context.Context, domain, value string) error {
+	return p.update(ctx, domain, value, false)
+}
+
+func (p *rfc2136Provider) update(ctx context.Context, domain, value string, add bool) error {
+	msg, id, err := p.buildMessage(domain, value, add)
+	if err != nil {
+		return fmt.Errorf("dns01/rfc2136: building UPDATE for %s: %w", domain, err)
+	}
+	if err := p.send(ctx, msg, id); err != nil {
+		return fmt.Errorf("dns01/rfc2136: sending UPDATE for %s: %w", domain, err)
+	}
+	return nil
+}
+
+// buildMessage encodes a minimal RFC 2136 UPDATE message: a one-record
+// zone section naming p.zone, an empty prerequisite section, and a
+// single update RR adding or removing the challenge TXT record, signed
+// with TSIG when configured.
+func (p *rfc2136Provider) buildMessage(domain, value string, add bool) ([]byte, uint16, error) {
+	id := uint16(rand.Intn(1 << 16))
+
+	var msg bytes.Buffer
+	binary.Write(&msg, binary.BigEndian, id)
+	binary.Write(&msg, binary.BigEndian, uint16(5<<11)) // opcode UPDATE
+	binary.Write(&msg, binary.BigEndian, uint16(1))     // ZOCOUNT
+	binary.Write(&msg, binary.BigEndian, uint16(0))     // PRCOUNT
+	binary.Write(&msg, binary.BigEndian, uint16(1))     // UPCOUNT
+	binary.Write(&msg, binary.BigEndian, uint16(0))     // ADCOUNT: set to 1 below once the TSIG RR is appended
+
+	msg.Write(encodeName(p.zone))
+	binary.Write(&msg, binary.BigEndian, uint16(6)) // TYPE SOA
+	binary.Write(&msg, binary.BigEndian, uint16(1)) // CLASS IN
+
+	name := "_acme-challenge." + strings.TrimSuffix(domain, ".") + "."
+	msg.Write(encodeName(name))
+	binary.Write(&msg, binary.BigEndian, uint16(16)) // TYPE TXT
+	if add {
+		binary.Write(&msg, binary.BigEndian, uint16(1)) // CLASS IN
+		binary.Write(&msg, binary.BigEndian, uint32(120))
+	} else {
+		binary.Write(&msg, binary.BigEndian, uint16(254)) // CLASS NONE: delete this rdata
+		binary.Write(&msg, binary.BigEndian, uint32(0))
+	}
+	rdata := append([]byte{byte(len(value))}, []byte(value)...)
+	binary.Write(&msg, binary.BigEndian, uint16(len(rdata)))
+	msg.Write(rdata)
+
+	out := msg.Bytes()
+	if p.tsigName != "" {
+		tsigRR, err := p.signTSIG(out, id)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, tsigRR...)
+		binary.BigEndian.PutUint16(out[10:12], 1) // ADCOUNT now counts the TSIG RR
+	}
+	return out, id, nil
+}
+
+// signTSIG computes the TSIG additional record RFC 2845 requires to
+// authenticate msg, using the configured key and algorithm.
+func (p *rfc2136Provider) signTSIG(msg []byte, id uint16) ([]byte, error) {
+	var newHash func() hash.Hash
+	switch p.tsigAlgo {
+	case "hmac-sha256.":
+		newHash = sha256.New
+	case "hmac-sha1.":
+		newHash = sha1.New
+	default:
+		return nil, fmt.Errorf("unsupported tsig_algorithm %q", p.tsigAlgo)
+	}
+
+	timeSigned := uint64(time.Now().Unix())
+	const fudge = uint16(300)
+
+	var variables bytes.Buffer
+	variables.Write(encodeName(p.tsigName))
+	binary.Write(&variables, binary.BigEndian, uint16(255)) // CLASS ANY
+	binary.Write(&variables, binary.BigEndian, uint32(0))   // TTL
+	variables.Write(encodeName(p.tsigAlgo))
+	writeUint48(&variables, timeSigned)
+	binary.Write(&variables, binary.BigEndian, fudge)
+	binary.Write(&variables, binary.BigEndian, uint16(0)) // error
+	binary.Write(&variables, binary.BigEndian, uint16(0)) // other len
+
+	h := hmac.New(newHash, p.tsigSecret)
+	h.Write(msg)
+	h.Write(variables.Bytes())
+	mac := h.Sum(nil)
+
+	var rdata bytes.Buffer
+	rdata.Write(encodeName(p.tsigAlgo))
+	writeUint48(&rdata, timeSigned)
+	binary.Write(&rdata, binary.BigEndian, fudge)
+	binary.Write(&rdata, binary.BigEndian, uint16(len(mac)))
+	rdata.Write(mac)
+	binary.Write(&rdata, binary.BigEndian, id)
+	binary.Write(&rdata, binary.BigEndian, uint16(0)) // error
+	binary.Write(&rdata, binary.BigEndian, uint16(0)) // other len
+
+	var rr bytes.Buffer
+	rr.Write(encodeName(p.tsigName))
+	binary.Write(&rr, binary.BigEndian, uint16(250)) // TYPE TSIG
+	binary.Write(&rr, binary.BigEndian, uint16(255)) // CLASS ANY
+	binary.Write(&rr, binary.BigEndian, uint32(0))   // TTL
+	binary.Write(&rr, binary.BigEndian, uint16(rdata.Len()))
+	rr.Write(rdata.Bytes())
+
+	return rr.Bytes(), nil
+}
+
+// send transmits msg to the nameserver over UDP and checks the response
+// header's RCODE.
+func (p *rfc2136Provider) send(ctx context.Context, msg []byte, id uint16) error {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "udp", p.nameserver)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write(msg); err != nil {
+		return err
+	}
+
+	resp := make([]byte, 512)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return err
+	}
+	if n < 12 || binary.BigEndian.Uint16(resp[0:2]) != id {
+		return fmt.Errorf("malformed or mismatched response from %s", p.nameserver)
+	}
+	if rcode := resp[3] & 0x0f; rcode != 0 {
+		return fmt.Errorf("nameserver %s rejected UPDATE with rcode %d", p.nameserver, rcode)
+	}
+	return nil
+}
+
+// encodeName encodes a trailing-dot DNS name into wire format.
+func encodeName(name string) []byte {
+	name = strings.TrimSuffix(name, ".")
+	var buf bytes.Buffer
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			buf.WriteByte(byte(len(label)))
+			buf.WriteString(label)
+		}
+	}
+	buf.WriteByte(0)
+	return buf.Bytes()
+}
+
+func writeUint48(buf *bytes.Buffer, v uint64) {
+	buf.WriteByte(byte(v >> 40))
+	buf.WriteByte(byte(v >> 32))
+	binary.Write(buf, binary.BigEndian, uint32(v))
+}
@@ -0,0 +1,169 @@
+package dns01
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// route53Provider satisfies DNS-01 challenges via the AWS Route53 REST
+// API, signed with AWS Signature Version 4 using only the standard
+// library (no AWS SDK dependency), the same convention
+// internal/provider's raw-HTTP providers use for their own backends.
+type route53Provider struct {
+	accessKeyID     string
+	secretAccessKey string
+	hostedZoneID    string
+	region          string // defaults to "us-east-1"; Route53 is global but still needs a SigV4 region
+	client          *http.Client
+}
+
+// newRoute53Provider builds a Provider from {"access_key_id": "...",
+// "secret_access_key": "...", "hosted_zone_id": "...", "region": "..."}.
+func newRoute53Provider(cfg map[string]string) (Provider, error) {
+	accessKeyID, secretKey, zoneID := cfg["access_key_id"], cfg["secret_access_key"], cfg["hosted_zone_id"]
+	if accessKeyID == "" || secretKey == "" || zoneID == "" {
+		return nil, fmt.Errorf("dns01/route53: access_key_id, secret_access_key, and hosted_zone_id are required")
+	}
+	region := cfg["region"]
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &route53Provider{
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretKey,
+		hostedZoneID:    zoneID,
+		region:          region,
+		client:          &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+type r53ChangeBatch struct {
+	XMLName xml.Name      `xml:"ChangeResourceRecordSetsRequest"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Batch   r53ChangesTag `xml:"ChangeBatch"`
+}
+
+type r53ChangesTag struct {
+	Changes []r53Change `xml:"Changes>Change"`
+}
+
+type r53Change struct {
+	Action            string `xml:"Action"`
+	ResourceRecordSet struct {
+		Name            string `xml:"Name"`
+		Type            string `xml:"Type"`
+		TTL             int    `xml:"TTL"`
+		ResourceRecords []struct {
+			Value string `xml:"Value"`
+		} `xml:"ResourceRecords>ResourceRecord"`
+	} `xml:"ResourceRecordSet"`
+}
+
+func (p *route53Provider) change(ctx context.Context, action, domain, value string) error {
+	name := "_acme-challenge." + domain + "."
+	batch := r53ChangeBatch{Xmlns: "https://route53.amazonaws.com/doc/2013-04-01/"}
+	change := r53Change{Action: action}
+	change.ResourceRecordSet.Name = name
+	change.ResourceRecordSet.Type = "TXT"
+	change.ResourceRecordSet.TTL = 120
+	change.ResourceRecordSet.ResourceRecords = append(change.ResourceRecordSet.ResourceRecords, struct {
+		Value string `xml:"Value"`
+	}{Value: `"` + value + `"`})
+	batch.Batch.Changes = []r53Change{change}
+
+	body, err := xml.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://route53.amazonaws.com/2013-04-01/hostedzone/%s/rrset", p.hostedZoneID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/xml")
+	p.sign(req, body)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Present creates the "_acme-challenge.<domain>" TXT record.
+func (p *route53Provider) Present(ctx context.Context, domain, value string) error {
+	if err := p.change(ctx, "UPSERT", domain, value); err != nil {
+		return fmt.Errorf("dns01/route53: creating TXT record for %s: %w", domain, err)
+	}
+	return nil
+}
+
+// CleanUp removes the TXT record Present created.
+func (p *route53Provider) CleanUp(ctx context.Context, domain, value string) error {
+	if err := p.change(ctx, "DELETE", domain, value); err != nil {
+		return fmt.Errorf("dns01/route53: removing TXT record for %s: %w", domain, err)
+	}
+	return nil
+}
+
+// sign adds AWS Signature Version 4 headers for the "route53" service.
+// Route53 is a global endpoint but SigV4 still requires a region; AWS
+// documents "us-east-1" as the value every client should use.
+func (p *route53Provider) sign(req *http.Request, body []byte) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	payloadHash := hex.EncodeToString(sha256Sum(body))
+
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-date:%s\n", req.URL.Host, amzDate)
+	signedHeaders := "host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.Path,
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	credentialScope := fmt.Sprintf("%s/%s/route53/aws4_request", dateStamp, p.region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		credentialScope,
+		hex.EncodeToString(sha256Sum([]byte(canonicalRequest))),
+	}, "\n")
+
+	signingKey := hmacSHA256(hmacSHA256(hmacSHA256(hmacSHA256([]byte("AWS4"+p.secretAccessKey), dateStamp), p.region), "route53"), "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	authHeader := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		p.accessKeyID, credentialScope, signedHeaders, signature)
+	req.Header.Set("Authorization", authHeader)
+}
+
+func sha256Sum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,125 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// devCA is a lazily generated, in-memory certificate authority used when
+// `dev_mode: true` so operators can bring up new domains locally without
+// ACME. It is never persisted: restarting GhostGate mints a new CA.
+type devCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newDevCA() (*devCA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "GhostGate Development CA", Organization: []string{"GhostGate"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &devCA{cert: cert, key: key, der: der}, nil
+}
+
+// issueLeaf mints a leaf certificate for host, signed by the dev CA.
+func (ca *devCA) issueLeaf(host string) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Certificate{
+		Certificate: [][]byte{der, ca.der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
+
+// devCert returns (minting if necessary) a leaf certificate for host from
+// the store's lazily generated development CA, caching the result so
+// repeat handshakes for the same host reuse it.
+func (s *Store) devCert(host string) (*tls.Certificate, error) {
+	s.mu.Lock()
+	if s.devCA == nil {
+		ca, err := newDevCA()
+		if err != nil {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("certs: generating dev CA: %w", err)
+		}
+		s.devCA = ca
+	}
+	ca := s.devCA
+	s.mu.Unlock()
+
+	if cert := s.lookup(host); cert != nil {
+		return cert, nil
+	}
+
+	leaf, err := ca.issueLeaf(host)
+	if err != nil {
+		return nil, fmt.Errorf("certs: issuing dev certificate for %q: %w", host, err)
+	}
+
+	s.mu.Lock()
+	s.certs[host] = leaf
+	s.mu.Unlock()
+
+	return leaf, nil
+}
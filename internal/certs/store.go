@@ -0,0 +1,356 @@
+// Package certs manages the TLS certificates GhostGate serves.
+package certs
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/ghostkellz/ghostgate/internal/metrics"
+)
+
+// acmeAccountsDir is the subdirectory of a Store's directory that ACME
+// account keys are persisted under, keyed by a hash of the CA's directory
+// URL so multiple CAs (or staging vs production) keep separate accounts.
+const acmeAccountsDir = "acme-accounts"
+
+// Store is a per-domain SNI certificate store. It scans a directory tree
+// for cert.pem/key.pem pairs, indexes them by CN and SAN (including
+// wildcard SANs), and serves them to tls.Config.GetCertificate. The
+// directory is watched so certificates can be dropped in or renewed
+// without restarting GhostGate.
+type Store struct {
+	mu        sync.RWMutex
+	certs     map[string]*tls.Certificate // exact hostname -> cert
+	wildcards map[string]*tls.Certificate // SAN suffix (without "*.") -> cert
+	entries   []CertInfo                  // one per loaded cert.pem/key.pem pair
+
+	dir     string
+	devMode bool
+	devCA   *devCA
+
+	watcher *fsnotify.Watcher
+}
+
+// CertInfo summarizes one certificate loaded into the store, for the
+// admin API's GET /api/v1/certs endpoint.
+type CertInfo struct {
+	Domain    string    `json:"domain"`
+	SANs      []string  `json:"sans"`
+	Issuer    string    `json:"issuer"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// NewStore builds a Store that scans dir for cert.pem/key.pem pairs and
+// watches it for changes. If devMode is true, certificates for unknown
+// hosts are minted on demand from a lazily generated, locally-trusted
+// development CA instead of returning an error.
+func NewStore(dir string, devMode bool) (*Store, error) {
+	s := &Store{
+		certs:     make(map[string]*tls.Certificate),
+		wildcards: make(map[string]*tls.Certificate),
+		dir:       dir,
+		devMode:   devMode,
+	}
+
+	if dir != "" {
+		if err := s.scan(); err != nil {
+			return nil, fmt.Errorf("scanning cert store %s: %w", dir, err)
+		}
+		// The watcher is armed synchronously, before NewStore returns, so
+		// that a caller who writes a new domain's cert.pem/key.pem right
+		// after NewStore (certs.Store.Install, used by the ACME manager
+		// and the admin API) can't race the goroutine below: every
+		// directory present at this point is already watched by the time
+		// anyone can write into a new one. Hot-reload is a convenience,
+		// not a hard requirement, so a watcher failure is logged rather
+		// than returned: the store still works, just without picking up
+		// changes until the next explicit Rescan.
+		if watcher, err := s.armWatcher(); err != nil {
+			log.Printf("[WARN] certs: could not start watcher: %v", err)
+		} else {
+			s.watcher = watcher
+			go s.watch()
+		}
+	}
+
+	return s, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate, resolving the
+// certificate for the SNI name in hello, falling back to the nearest
+// wildcard and, in dev mode, to a freshly minted leaf certificate.
+func (s *Store) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	host := strings.ToLower(hello.ServerName)
+	if host == "" {
+		return nil, fmt.Errorf("certs: client did not send SNI")
+	}
+
+	if cert := s.lookup(host); cert != nil {
+		return cert, nil
+	}
+
+	if s.devMode {
+		return s.devCert(host)
+	}
+
+	return nil, fmt.Errorf("certs: no certificate found for %q", host)
+}
+
+// lookup returns the best matching certificate for host, checking exact
+// names first and then the wildcard covering its parent domain, e.g.
+// "a.b.example.com" falls back to "*.b.example.com".
+func (s *Store) lookup(host string) *tls.Certificate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if cert, ok := s.certs[host]; ok {
+		return cert
+	}
+	if idx := strings.IndexByte(host, '.'); idx != -1 {
+		parent := host[idx+1:]
+		if cert, ok := s.wildcards[parent]; ok {
+			return cert
+		}
+	}
+	return nil
+}
+
+// scan walks dir for cert.pem/key.pem pairs and atomically swaps them
+// into the store.
+func (s *Store) scan() error {
+	certs := make(map[string]*tls.Certificate)
+	wildcards := make(map[string]*tls.Certificate)
+	var entries []CertInfo
+
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if info.Name() != "cert.pem" {
+			return nil
+		}
+		keyPath := filepath.Join(filepath.Dir(path), "key.pem")
+		cert, err := tls.LoadX509KeyPair(path, keyPath)
+		if err != nil {
+			log.Printf("[WARN] certs: skipping %s: %v", path, err)
+			return nil
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			log.Printf("[WARN] certs: could not parse %s: %v", path, err)
+			return nil
+		}
+		cert.Leaf = leaf
+
+		names := leaf.DNSNames
+		if leaf.Subject.CommonName != "" {
+			names = append(names, leaf.Subject.CommonName)
+		}
+		for _, name := range names {
+			name = strings.ToLower(name)
+			if strings.HasPrefix(name, "*.") {
+				wildcards[name[2:]] = &cert
+			} else {
+				certs[name] = &cert
+			}
+		}
+		if len(names) > 0 {
+			metrics.CertExpiryDays.WithLabelValues(strings.ToLower(names[0])).Set(time.Until(leaf.NotAfter).Hours() / 24)
+			entries = append(entries, CertInfo{
+				Domain:    strings.ToLower(names[0]),
+				SANs:      leaf.DNSNames,
+				Issuer:    leaf.Issuer.CommonName,
+				NotBefore: leaf.NotBefore,
+				NotAfter:  leaf.NotAfter,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.certs = certs
+	s.wildcards = wildcards
+	s.entries = entries
+	s.mu.Unlock()
+
+	metrics.CertsLoaded.Set(float64(len(certs)))
+	log.Printf("[INFO] certs: loaded %d certificate(s), %d wildcard(s) from %s", len(certs), len(wildcards), s.dir)
+	return nil
+}
+
+// Status returns a summary of every certificate currently loaded, for the
+// admin API.
+func (s *Store) Status() []CertInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]CertInfo, len(s.entries))
+	copy(out, s.entries)
+	return out
+}
+
+// Rescan re-walks the store's directory for new or renewed certificates.
+// It picks up whatever an external issuer (GhostGate's own ACME manager,
+// certbot, an operator dropping files in by hand, ...) has written to dir.
+func (s *Store) Rescan() error {
+	return s.scan()
+}
+
+// ExpiresAt returns the NotAfter time of the loaded certificate whose
+// primary name matches domain, or the zero Time if none is loaded. The
+// ACME manager uses this to decide whether a domain is due for renewal.
+func (s *Store) ExpiresAt(domain string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	domain = strings.ToLower(domain)
+	for _, e := range s.entries {
+		if e.Domain == domain {
+			return e.NotAfter
+		}
+	}
+	return time.Time{}
+}
+
+// Install writes a freshly issued certificate and private key for domain
+// into dir/domain/cert.pem and dir/domain/key.pem and rescans, so it is
+// immediately served over SNI and reported by the admin API. It is how
+// GhostGate's ACME manager publishes a certificate once issued or
+// renewed.
+func (s *Store) Install(domain string, certPEM, keyPEM []byte) error {
+	if s.dir == "" {
+		return fmt.Errorf("certs: store has no directory to install a certificate for %q into", domain)
+	}
+
+	domainDir := filepath.Join(s.dir, domain)
+	if err := os.MkdirAll(domainDir, 0o755); err != nil {
+		return fmt.Errorf("certs: creating directory for %q: %w", domain, err)
+	}
+	if err := os.WriteFile(filepath.Join(domainDir, "cert.pem"), certPEM, 0o644); err != nil {
+		return fmt.Errorf("certs: writing certificate for %q: %w", domain, err)
+	}
+	if err := os.WriteFile(filepath.Join(domainDir, "key.pem"), keyPEM, 0o600); err != nil {
+		return fmt.Errorf("certs: writing private key for %q: %w", domain, err)
+	}
+
+	return s.scan()
+}
+
+// SaveACMEAccountKey persists an ACME account's private key (SEC1 DER, as
+// produced by x509.MarshalECPrivateKey) under the store's directory,
+// keyed by the CA's directory URL, so GhostGate's ACME manager reuses the
+// same account across restarts instead of registering a new one every
+// time.
+func (s *Store) SaveACMEAccountKey(dirURL string, keyDER []byte) error {
+	if s.dir == "" {
+		return fmt.Errorf("certs: store has no directory to persist an ACME account key into")
+	}
+	dir := filepath.Join(s.dir, acmeAccountsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("certs: creating ACME accounts directory: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, acmeAccountFileName(dirURL)), keyDER, 0o600)
+}
+
+// LoadACMEAccountKey returns the ACME account private key previously
+// saved by SaveACMEAccountKey for dirURL, or an error satisfying
+// os.IsNotExist if no account key has been saved for it yet.
+func (s *Store) LoadACMEAccountKey(dirURL string) ([]byte, error) {
+	if s.dir == "" {
+		return nil, os.ErrNotExist
+	}
+	return os.ReadFile(filepath.Join(s.dir, acmeAccountsDir, acmeAccountFileName(dirURL)))
+}
+
+// acmeAccountFileName derives a file name for dirURL's account key from
+// its SHA-256 hash, so differing CA directory URLs (production vs
+// staging, a self-hosted CA, ...) never collide and dirURL never has to
+// be sanitized for filesystem-unsafe characters.
+func acmeAccountFileName(dirURL string) string {
+	sum := sha256.Sum256([]byte(dirURL))
+	return hex.EncodeToString(sum[:]) + ".key"
+}
+
+// armWatcher creates an fsnotify.Watcher and adds every directory under
+// s.dir to it. It is called synchronously from NewStore, before any
+// caller can observe a *Store, so that the directories present at
+// construction time are guaranteed to be watched before watch's event
+// loop goroutine even starts.
+func (s *Store) armWatcher() (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	return watcher, nil
+}
+
+// watch recompiles the store whenever a file under dir changes. The
+// watcher itself is armed by armWatcher before this runs; watch only
+// drives its event loop.
+func (s *Store) watch() {
+	watcher := s.watcher
+	defer watcher.Close()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Create != 0 {
+				// A newly created directory (e.g. a fresh domain's
+				// cert/key pair dropped in by ACME or an operator) isn't
+				// watched yet; fsnotify only watches what Add saw at
+				// startup. Add it now so its own Create/Write events are
+				// seen too, instead of only picking it up on the next
+				// Rescan or restart.
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watcher.Add(event.Name); err != nil {
+						log.Printf("[WARN] certs: could not watch new directory %s: %v", event.Name, err)
+					}
+				}
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+				if err := s.scan(); err != nil {
+					log.Printf("[WARN] certs: rescan of %s failed: %v", s.dir, err)
+				}
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[WARN] certs: watcher error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSignedCert writes a cert.pem/key.pem pair for domain into dir,
+// valid enough for Store.scan to index it.
+func writeSelfSignedCert(t *testing.T, dir, domain string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshaling key: %v", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", dir, err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, "cert.pem"), certPEM, 0o644); err != nil {
+		t.Fatalf("writing cert.pem: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(filepath.Join(dir, "key.pem"), keyPEM, 0o600); err != nil {
+		t.Fatalf("writing key.pem: %v", err)
+	}
+}
+
+func TestACMEAccountKeyRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir, false)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	const dirURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+	if _, err := store.LoadACMEAccountKey(dirURL); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadACMEAccountKey on empty store: got err %v, want os.ErrNotExist", err)
+	}
+
+	want := []byte("fake-account-key-der")
+	if err := store.SaveACMEAccountKey(dirURL, want); err != nil {
+		t.Fatalf("SaveACMEAccountKey: %v", err)
+	}
+
+	got, err := store.LoadACMEAccountKey(dirURL)
+	if err != nil {
+		t.Fatalf("LoadACMEAccountKey after save: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("LoadACMEAccountKey = %q, want %q", got, want)
+	}
+
+	// A different directory URL (e.g. staging) must not share the key.
+	if _, err := store.LoadACMEAccountKey(dirURL + "/staging"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadACMEAccountKey for a different dirURL: got err %v, want os.ErrNotExist", err)
+	}
+}
+
+// TestWatchPicksUpNewDomainDirectory verifies that a cert.pem/key.pem
+// pair dropped into a brand-new subdirectory created after NewStore is
+// still picked up by the fsnotify watcher, not just on the next Rescan.
+func TestWatchPicksUpNewDomainDirectory(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir, false)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	writeSelfSignedCert(t, filepath.Join(dir, "late.example.com"), "late.example.com")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if store.lookup("late.example.com") != nil {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("watcher never picked up a certificate written into a directory created after NewStore")
+}
@@ -0,0 +1,160 @@
+// Package clientip resolves the IP address GhostGate's ACLs and rate
+// limiter should treat as "the client", honoring a domain's trusted
+// proxies and real-IP header instead of trusting the raw TCP peer when
+// GhostGate sits behind a load balancer or CDN.
+package clientip
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Resolver resolves the real client IP for requests to one domain.
+type Resolver struct {
+	trusted      []netip.Prefix
+	realIPHeader string
+}
+
+// NewResolver builds a Resolver from a domain's trusted_proxies (CIDRs or
+// bare IPs) and real_ip_header config. Invalid entries in trustedProxies
+// are logged and skipped rather than failing the whole domain.
+func NewResolver(domain string, trustedProxies []string, realIPHeader string) *Resolver {
+	return &Resolver{
+		trusted:      ParsePrefixes(domain, "trusted_proxies", trustedProxies),
+		realIPHeader: realIPHeader,
+	}
+}
+
+// ParsePrefixes parses patterns (CIDRs or bare IPs) into netip.Prefix,
+// logging and skipping any entry it can't parse rather than failing the
+// whole domain's ACLs.
+func ParsePrefixes(domain, field string, patterns []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(patterns))
+	for _, p := range patterns {
+		prefix, err := parsePrefix(p)
+		if err != nil {
+			log.Printf("domain %s: skipping invalid %s entry %q: %v", domain, field, p, err)
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ContainsIP reports whether ip falls within any of prefixes.
+func ContainsIP(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerAddr extracts the connection's IP from an http.Request.RemoteAddr,
+// which is normally "host:port".
+func peerAddr(remoteAddr string) (netip.Addr, bool) {
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr, true
+}
+
+// Resolve returns the IP ACLs and rate limiting should key on. If the
+// connecting peer is not a trusted proxy, that peer's own address is used
+// as-is. Otherwise the configured real-IP header (if any) or
+// X-Forwarded-For/Forwarded is consulted to recover the real client
+// behind the proxy.
+func (res *Resolver) Resolve(r *http.Request) netip.Addr {
+	peer, ok := peerAddr(r.RemoteAddr)
+	if !ok {
+		return netip.Addr{}
+	}
+	if len(res.trusted) == 0 || !ContainsIP(res.trusted, peer) {
+		return peer
+	}
+
+	if res.realIPHeader != "" {
+		if v := strings.TrimSpace(r.Header.Get(res.realIPHeader)); v != "" {
+			if addr, err := netip.ParseAddr(v); err == nil {
+				return addr
+			}
+		}
+	}
+
+	chain := forwardedChain(r)
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(chain[i])
+		if err != nil {
+			continue
+		}
+		if !ContainsIP(res.trusted, addr) {
+			return addr
+		}
+	}
+	if len(chain) > 0 {
+		if addr, err := netip.ParseAddr(chain[0]); err == nil {
+			return addr
+		}
+	}
+	return peer
+}
+
+// forwardedChain returns the hop-by-hop client IP chain carried by
+// X-Forwarded-For (preferred) or, failing that, the "for=" parameters of
+// the standardized Forwarded header, in the order the hops were added.
+func forwardedChain(r *http.Request) []string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		parts := strings.Split(xff, ",")
+		chain := make([]string, 0, len(parts))
+		for _, p := range parts {
+			chain = append(chain, strings.TrimSpace(p))
+		}
+		return chain
+	}
+	fwd := r.Header.Get("Forwarded")
+	if fwd == "" {
+		return nil
+	}
+	var chain []string
+	for _, part := range strings.Split(fwd, ",") {
+		for _, kv := range strings.Split(part, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(kv), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(k), "for") {
+				continue
+			}
+			v = strings.Trim(strings.TrimSpace(v), `"`)
+			// SplitHostPort must run before stripping "[...]" brackets: it
+			// needs them intact to tell a bracketed IPv6 host from its
+			// trailing port. Only once that fails (no port present) do we
+			// strip a bare "[2001:db8::1]" down to the address itself.
+			if host, _, err := net.SplitHostPort(v); err == nil {
+				v = host
+			} else {
+				v = strings.TrimPrefix(v, "[")
+				v = strings.TrimSuffix(v, "]")
+			}
+			chain = append(chain, v)
+		}
+	}
+	return chain
+}
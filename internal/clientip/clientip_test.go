@@ -0,0 +1,153 @@
+package clientip
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+)
+
+func mustPrefix(t *testing.T, s string) netip.Prefix {
+	t.Helper()
+	p, err := parsePrefix(s)
+	if err != nil {
+		t.Fatalf("parsePrefix(%q): %v", s, err)
+	}
+	return p
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"IPv4 CIDR", "10.0.0.0/8", false},
+		{"bare IPv4", "192.168.1.1", false},
+		{"IPv6 CIDR", "2001:db8::/32", false},
+		{"bare IPv6", "::1", false},
+		{"invalid", "not-an-ip", true},
+		{"invalid CIDR suffix", "10.0.0.0/99", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePrefix(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parsePrefix(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParsePrefixBareIPIsSingleHost(t *testing.T) {
+	p := mustPrefix(t, "192.168.1.1")
+	if p.Bits() != 32 {
+		t.Errorf("bare IPv4 prefix bits = %d, want 32", p.Bits())
+	}
+	if !p.Contains(netip.MustParseAddr("192.168.1.1")) {
+		t.Error("prefix does not contain its own address")
+	}
+	if p.Contains(netip.MustParseAddr("192.168.1.2")) {
+		t.Error("bare-IP prefix matched a different address")
+	}
+}
+
+func TestContainsIP(t *testing.T) {
+	prefixes := []netip.Prefix{
+		mustPrefix(t, "10.0.0.0/8"),
+		mustPrefix(t, "192.168.1.1"),
+	}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"192.168.1.1", true},
+		{"192.168.1.2", false},
+		{"172.16.0.1", false},
+	}
+	for _, tt := range tests {
+		got := ContainsIP(prefixes, netip.MustParseAddr(tt.ip))
+		if got != tt.want {
+			t.Errorf("ContainsIP(%q) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestResolveUntrustedPeerIgnoresForwardedFor(t *testing.T) {
+	res := NewResolver("example.com", []string{"10.0.0.0/8"}, "")
+	r := httpRequestFrom("203.0.113.5:1234", map[string]string{
+		"X-Forwarded-For": "198.51.100.9",
+	})
+
+	got := res.Resolve(r)
+	if got.String() != "203.0.113.5" {
+		t.Errorf("Resolve = %s, want the untrusted peer's own address", got)
+	}
+}
+
+func TestResolveTrustedPeerWalksForwardedForFromTheRight(t *testing.T) {
+	res := NewResolver("example.com", []string{"10.0.0.0/8"}, "")
+	// Chain: real-client, some-untrusted-hop, trusted-lb (the peer).
+	r := httpRequestFrom("10.0.0.1:1234", map[string]string{
+		"X-Forwarded-For": "203.0.113.5, 198.51.100.9, 10.0.0.2",
+	})
+
+	got := res.Resolve(r)
+	if got.String() != "198.51.100.9" {
+		t.Errorf("Resolve = %s, want the rightmost untrusted hop", got)
+	}
+}
+
+func TestResolveTrustedPeerPrefersRealIPHeader(t *testing.T) {
+	res := NewResolver("example.com", []string{"10.0.0.0/8"}, "CF-Connecting-IP")
+	r := httpRequestFrom("10.0.0.1:1234", map[string]string{
+		"CF-Connecting-IP": "203.0.113.5",
+		"X-Forwarded-For":  "198.51.100.9",
+	})
+
+	got := res.Resolve(r)
+	if got.String() != "203.0.113.5" {
+		t.Errorf("Resolve = %s, want the real-IP header value", got)
+	}
+}
+
+func TestResolveFallsBackToLeftmostHopWhenChainIsAllTrusted(t *testing.T) {
+	res := NewResolver("example.com", []string{"10.0.0.0/8"}, "")
+	r := httpRequestFrom("10.0.0.1:1234", map[string]string{
+		"X-Forwarded-For": "10.0.0.2, 10.0.0.3",
+	})
+
+	// No hop in the chain is untrusted; Resolve falls back to the
+	// leftmost (oldest) hop as its best guess rather than the peer.
+	got := res.Resolve(r)
+	if got.String() != "10.0.0.2" {
+		t.Errorf("Resolve = %s, want the leftmost hop", got)
+	}
+}
+
+func TestForwardedChainFromStandardHeader(t *testing.T) {
+	r := httpRequestFrom("10.0.0.1:1234", map[string]string{
+		"Forwarded": `for=203.0.113.5;proto=https, for="[2001:db8::1]:1234";proto=https`,
+	})
+
+	chain := forwardedChain(r)
+	want := []string{"203.0.113.5", "2001:db8::1"}
+	if len(chain) != len(want) {
+		t.Fatalf("forwardedChain = %v, want %v", chain, want)
+	}
+	for i := range want {
+		if chain[i] != want[i] {
+			t.Errorf("forwardedChain[%d] = %q, want %q", i, chain[i], want[i])
+		}
+	}
+}
+
+func httpRequestFrom(remoteAddr string, headers map[string]string) *http.Request {
+	r, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	r.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	return r
+}
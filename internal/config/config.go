@@ -0,0 +1,295 @@
+// Package config loads and validates GhostGate's YAML configuration.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ghostkellz/ghostgate/internal/acme/dns01"
+)
+
+// ProxyRoute describes a single path-to-backend mapping within a domain.
+type ProxyRoute struct {
+	Path      string `yaml:"path" json:"path"`
+	Backend   string `yaml:"backend" json:"backend"`
+	Regex     bool   `yaml:"regex" json:"regex"`
+	RateLimit int    `yaml:"rate_limit" json:"rate_limit"`
+	// RateLimitBurst caps how many requests a single client can burst
+	// above RateLimit before being throttled. Falls back to the domain's
+	// RateLimitBurst, then to a default of 5, if zero.
+	RateLimitBurst int               `yaml:"rate_limit_burst" json:"rate_limit_burst"`
+	Headers        map[string]string `yaml:"headers" json:"headers,omitempty"`
+	// Cache opts this route into GhostGate's RFC 7234-aware response
+	// cache. Off by default since caching is only safe to turn on once an
+	// operator has checked the backend sends correct Cache-Control/Vary.
+	Cache bool `yaml:"cache" json:"cache"`
+}
+
+// DomainConfig describes a single virtual host served by GhostGate.
+type DomainConfig struct {
+	Domain      string       `yaml:"domain" json:"domain"`
+	DomainRegex bool         `yaml:"domain_regex" json:"domain_regex"`
+	StaticDir   string       `yaml:"static_dir" json:"static_dir,omitempty"`
+	ProxyRoutes []ProxyRoute `yaml:"proxy_routes" json:"proxy_routes,omitempty"`
+	Autocert    bool         `yaml:"autocert" json:"autocert"`
+	ACMEEmail   string       `yaml:"acme_email" json:"acme_email,omitempty"`
+	// ACME configures how a certificate is obtained when Autocert is true.
+	// The zero value issues from Let's Encrypt via an HTTP-01 challenge on
+	// GhostGate's own :80 listener.
+	ACME            ACMEConfig `yaml:"acme" json:"acme"`
+	RedirectToHTTPS bool       `yaml:"redirect_to_https" json:"redirect_to_https"`
+	HSTS            bool       `yaml:"hsts" json:"hsts"`
+	CSP             string     `yaml:"csp" json:"csp,omitempty"`
+	AllowIPs        []string   `yaml:"allow_ips" json:"allow_ips,omitempty"`
+	DenyIPs         []string   `yaml:"deny_ips" json:"deny_ips,omitempty"`
+	// TrustedProxies lists CIDRs (or bare IPs) of reverse proxies/load
+	// balancers allowed to report the real client IP via RealIPHeader or
+	// X-Forwarded-For/Forwarded. Requests from any other peer have their
+	// own connection address used as-is, ignoring those headers.
+	TrustedProxies []string `yaml:"trusted_proxies" json:"trusted_proxies,omitempty"`
+	// RealIPHeader names a single-value header (e.g. "X-Real-IP" or
+	// "CF-Connecting-IP") a trusted proxy sets to the real client IP. If
+	// empty, the client IP is derived by walking X-Forwarded-For/Forwarded
+	// from the right, skipping trusted hops.
+	RealIPHeader string `yaml:"real_ip_header" json:"real_ip_header,omitempty"`
+	// RateLimit and RateLimitBurst are the domain-wide defaults a
+	// ProxyRoute falls back to when it doesn't set its own.
+	RateLimit      int             `yaml:"rate_limit" json:"rate_limit"`
+	RateLimitBurst int             `yaml:"rate_limit_burst" json:"rate_limit_burst"`
+	AccessLog      AccessLogConfig `yaml:"access_log" json:"access_log"`
+}
+
+// ACMEConfig configures native ACME certificate issuance for one domain.
+// GhostGate's ACME manager (internal/acme) reads it, writes account keys
+// and issued certificates into server.certs_dir, and renews automatically
+// starting 30 days before expiry.
+type ACMEConfig struct {
+	// DirectoryURL is the ACME server's directory endpoint. Defaults to
+	// Let's Encrypt's production directory.
+	DirectoryURL string `yaml:"directory_url" json:"directory_url,omitempty"`
+	// Challenge is "http-01" (default, answered on GhostGate's :80
+	// listener) or "dns-01" (answered via DNSProvider).
+	Challenge string `yaml:"challenge" json:"challenge,omitempty"`
+	// DNSProvider names the DNS-01 provider to use: "cloudflare",
+	// "route53", "powerdns", or "rfc2136". Required when Challenge is
+	// "dns-01".
+	DNSProvider string `yaml:"dns_provider" json:"dns_provider,omitempty"`
+	// DNSProviderConfig holds the provider's credentials and settings,
+	// e.g. {"api_token": "...", "zone_id": "..."} for Cloudflare. See the
+	// internal/acme/dns01 provider implementations for the keys each one
+	// expects.
+	DNSProviderConfig map[string]string `yaml:"dns_provider_config" json:"dns_provider_config,omitempty"`
+	// EABKeyID and EABHMACKey configure External Account Binding, required
+	// by CAs that don't allow anonymous registration such as ZeroSSL and
+	// Google Trust Services. EABHMACKey is base64url-encoded, matching
+	// what those CAs hand out.
+	EABKeyID   string `yaml:"eab_key_id" json:"eab_key_id,omitempty"`
+	EABHMACKey string `yaml:"eab_hmac_key" json:"eab_hmac_key,omitempty"`
+}
+
+// AccessLogConfig configures where and how one domain's per-request
+// access log lines are written. The zero value logs text-formatted lines
+// to stdout.
+type AccessLogConfig struct {
+	// Output is "stdout" (default), "file", or "syslog".
+	Output string `yaml:"output" json:"output,omitempty"`
+	// Path is the log file path; required when Output is "file".
+	Path string `yaml:"path" json:"path,omitempty"`
+	// Format is "text" (default), "json", "common" (CLF), or "combined".
+	Format string `yaml:"format" json:"format,omitempty"`
+}
+
+// AdminAPIAuthConfig configures how GhostGate's admin API authenticates
+// requests. The zero value requires no authentication.
+type AdminAPIAuthConfig struct {
+	// Token, if set, is the bearer token every admin API request must
+	// present as "Authorization: Bearer <token>".
+	Token string `yaml:"token" json:"token,omitempty"`
+	// ClientCA, if set, is a PEM file of CA certificates; the admin
+	// listener requires and verifies a client certificate against it
+	// (mTLS) in addition to any Token check.
+	ClientCA string `yaml:"client_ca" json:"client_ca,omitempty"`
+}
+
+// Config is the top-level GhostGate configuration.
+type Config struct {
+	Server struct {
+		Port int `yaml:"port" json:"port"`
+		// CertsDir is the SNI certificate store's directory (see
+		// internal/certs.Store). It is required to serve HTTPS at all:
+		// it holds cert.pem/key.pem pairs dropped in by an operator or
+		// written by GhostGate's own ACME manager for any domain with
+		// autocert enabled. In dev_mode, hosts with no certificate there
+		// get one minted on demand by the dev CA, kept in memory only and
+		// never written to this directory.
+		CertsDir string `yaml:"certs_dir" json:"certs_dir,omitempty"`
+		DevMode  bool   `yaml:"dev_mode" json:"dev_mode"`
+		AdminAPI string `yaml:"admin_api" json:"admin_api,omitempty"`
+		// AdminAPIAuth locks down the admin API configured via AdminAPI.
+		// Leaving it unset is only safe when AdminAPI binds to a
+		// loopback/private address.
+		AdminAPIAuth AdminAPIAuthConfig `yaml:"admin_api_auth" json:"admin_api_auth"`
+	} `yaml:"server" json:"server"`
+	Logging struct {
+		Level  string `yaml:"level" json:"level,omitempty"`
+		Format string `yaml:"format" json:"format,omitempty"`
+		// TimeFormat is a time.Layout reference timestamp for log lines.
+		// Defaults to time.RFC3339 if empty.
+		TimeFormat string `yaml:"time_format" json:"time_format,omitempty"`
+	} `yaml:"logging" json:"logging"`
+	// RateLimit configures the backend rate limiting is enforced against.
+	// If RedisAddr is empty, each GhostGate instance enforces limits
+	// in-memory; set it to share one GCRA bucket across instances.
+	RateLimit struct {
+		RedisAddr string `yaml:"redis_addr" json:"redis_addr,omitempty"`
+	} `yaml:"rate_limit" json:"rate_limit"`
+	Domains        []DomainConfig `yaml:"domains" json:"domains"`
+	ReloadOnChange bool           `yaml:"reload_on_change" json:"reload_on_change"`
+	// DynamicProviders turns on additional domain-discovery sources
+	// (Docker, Consul, etcd) merged alongside Domains by an
+	// internal/watcher.Watcher. See internal/provider.
+	DynamicProviders DynamicProvidersConfig `yaml:"dynamic_providers" json:"dynamic_providers"`
+}
+
+// DynamicProvidersConfig turns on GhostGate's pluggable dynamic
+// configuration providers (internal/provider): Domains from the main
+// config and conf.d are always served; each enabled provider here is
+// merged in alongside them, last-write-wins per domain, by
+// internal/watcher.Watcher.
+type DynamicProvidersConfig struct {
+	Docker DockerProviderConfig `yaml:"docker" json:"docker"`
+	Consul ConsulProviderConfig `yaml:"consul" json:"consul"`
+	Etcd   EtcdProviderConfig   `yaml:"etcd" json:"etcd"`
+}
+
+// DockerProviderConfig configures discovery of domains from running
+// container labels (ghostgate.domain, ghostgate.backend). See
+// internal/provider/docker.
+type DockerProviderConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Endpoint is the Docker daemon socket. Defaults to
+	// /var/run/docker.sock.
+	Endpoint string `yaml:"endpoint" json:"endpoint,omitempty"`
+	// PollIntervalSeconds controls how often containers are re-listed.
+	// Defaults to 5.
+	PollIntervalSeconds int `yaml:"poll_interval_seconds" json:"poll_interval_seconds,omitempty"`
+}
+
+// ConsulProviderConfig configures discovery of domains from a Consul KV
+// prefix. See internal/provider/consul.
+type ConsulProviderConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Address is the Consul HTTP API base URL, e.g. http://127.0.0.1:8500.
+	Address string `yaml:"address" json:"address,omitempty"`
+	// Prefix is the KV prefix to watch, e.g. ghostgate/domains.
+	Prefix string `yaml:"prefix" json:"prefix,omitempty"`
+	// PollIntervalSeconds controls how often the prefix is re-read.
+	// Defaults to 5.
+	PollIntervalSeconds int `yaml:"poll_interval_seconds" json:"poll_interval_seconds,omitempty"`
+}
+
+// EtcdProviderConfig configures discovery of domains from an etcd v3 key
+// prefix. See internal/provider/etcd.
+type EtcdProviderConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Endpoint is the etcd gRPC-gateway base URL, e.g. http://127.0.0.1:2379.
+	Endpoint string `yaml:"endpoint" json:"endpoint,omitempty"`
+	// Prefix is the key prefix to watch, e.g. /ghostgate/domains/.
+	Prefix string `yaml:"prefix" json:"prefix,omitempty"`
+	// PollIntervalSeconds controls how often the prefix is re-read.
+	// Defaults to 5.
+	PollIntervalSeconds int `yaml:"poll_interval_seconds" json:"poll_interval_seconds,omitempty"`
+}
+
+// Load reads and parses a single YAML configuration file.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses a single YAML configuration document from memory, e.g. one
+// submitted to the admin API's PUT /api/v1/config endpoint.
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// LoadWithConfDir reads the main configuration file and merges in every
+// *.conf file found in confDir, in the style of Apache/Nginx conf.d
+// directories.
+func LoadWithConfDir(mainConfigPath, confDir string) (*Config, error) {
+	mainConfig, err := Load(mainConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(confDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".conf") {
+			confPath := filepath.Join(confDir, file.Name())
+			additionalConfig, err := Load(confPath)
+			if err != nil {
+				log.Printf("Failed to load config %s: %v", confPath, err)
+				continue
+			}
+			mainConfig.Domains = append(mainConfig.Domains, additionalConfig.Domains...)
+		}
+	}
+
+	return mainConfig, nil
+}
+
+// Validate checks that a configuration is complete enough to run: it
+// rejects the same malformed backend URLs, duplicate domains, and
+// unusable DNS-01 provider config that would otherwise only surface
+// later, silently, when Server.buildMux or the ACME manager hits them.
+func Validate(cfg *Config) error {
+	if cfg.Server.Port == 0 {
+		return fmt.Errorf("server port is not defined")
+	}
+	if len(cfg.Domains) == 0 {
+		return fmt.Errorf("at least one domain must be defined")
+	}
+
+	seen := make(map[string]bool, len(cfg.Domains))
+	for _, d := range cfg.Domains {
+		if seen[d.Domain] {
+			return fmt.Errorf("domain %q is defined more than once", d.Domain)
+		}
+		seen[d.Domain] = true
+
+		for _, route := range d.ProxyRoutes {
+			backendURL, err := url.Parse(route.Backend)
+			if err != nil {
+				return fmt.Errorf("domain %q path %q: invalid backend URL %q: %w", d.Domain, route.Path, route.Backend, err)
+			}
+			if backendURL.Scheme == "" || backendURL.Host == "" {
+				return fmt.Errorf("domain %q path %q: backend URL %q must be absolute (scheme and host)", d.Domain, route.Path, route.Backend)
+			}
+		}
+
+		if d.Autocert && d.ACME.Challenge == "dns-01" {
+			if _, err := dns01.New(d.ACME.DNSProvider, d.ACME.DNSProviderConfig); err != nil {
+				return fmt.Errorf("domain %q: invalid dns-01 provider config: %w", d.Domain, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func validConfig() *Config {
+	cfg := &Config{}
+	cfg.Server.Port = 443
+	cfg.Domains = []DomainConfig{
+		{
+			Domain: "example.com",
+			ProxyRoutes: []ProxyRoute{
+				{Path: "/", Backend: "http://127.0.0.1:8080"},
+			},
+		},
+	}
+	return cfg
+}
+
+func TestValidateAcceptsWellFormedConfig(t *testing.T) {
+	if err := Validate(validConfig()); err != nil {
+		t.Fatalf("Validate rejected a well-formed config: %v", err)
+	}
+}
+
+func TestValidateRejectsDuplicateDomains(t *testing.T) {
+	cfg := validConfig()
+	cfg.Domains = append(cfg.Domains, DomainConfig{Domain: "example.com"})
+
+	err := Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Fatalf("expected a duplicate-domain error, got %v", err)
+	}
+}
+
+func TestValidateRejectsMalformedBackendURL(t *testing.T) {
+	cfg := validConfig()
+	cfg.Domains[0].ProxyRoutes[0].Backend = "not a url"
+
+	err := Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), "backend URL") {
+		t.Fatalf("expected a backend URL error, got %v", err)
+	}
+}
+
+func TestValidateRejectsIncompleteDNS01Config(t *testing.T) {
+	cfg := validConfig()
+	cfg.Domains[0].Autocert = true
+	cfg.Domains[0].ACME = ACMEConfig{
+		Challenge:   "dns-01",
+		DNSProvider: "cloudflare",
+		// Missing api_token/zone_id.
+	}
+
+	err := Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), "dns-01 provider") {
+		t.Fatalf("expected a dns-01 provider config error, got %v", err)
+	}
+}
+
+func TestParseDynamicProviders(t *testing.T) {
+	cfg, err := Parse([]byte(`
+server:
+  port: 443
+domains:
+  - domain: example.com
+dynamic_providers:
+  docker:
+    enabled: true
+    endpoint: /var/run/docker.sock
+    poll_interval_seconds: 10
+  consul:
+    enabled: true
+    address: http://127.0.0.1:8500
+    prefix: ghostgate/domains
+  etcd:
+    enabled: false
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	dp := cfg.DynamicProviders
+	if !dp.Docker.Enabled || dp.Docker.Endpoint != "/var/run/docker.sock" || dp.Docker.PollIntervalSeconds != 10 {
+		t.Errorf("docker provider config parsed incorrectly: %+v", dp.Docker)
+	}
+	if !dp.Consul.Enabled || dp.Consul.Address != "http://127.0.0.1:8500" || dp.Consul.Prefix != "ghostgate/domains" {
+		t.Errorf("consul provider config parsed incorrectly: %+v", dp.Consul)
+	}
+	if dp.Etcd.Enabled {
+		t.Errorf("etcd provider set to enabled: false parsed as enabled: %+v", dp.Etcd)
+	}
+}
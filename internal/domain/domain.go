@@ -0,0 +1,34 @@
+// Package domain resolves incoming requests to the virtual host that
+// should handle them.
+package domain
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/ghostkellz/ghostgate/internal/config"
+)
+
+// Matches reports whether host satisfies the given domain configuration,
+// honoring the DomainRegex flag for regex-based virtual hosts.
+func Matches(d config.DomainConfig, host string) bool {
+	if d.DomainRegex {
+		matched, _ := regexp.MatchString(d.Domain, host)
+		return matched
+	}
+	return host == d.Domain || strings.HasPrefix(host, d.Domain+":")
+}
+
+// HostHandler wraps handler so it only serves requests whose Host header
+// matches the given domain, returning 404 for everything else.
+func HostHandler(d config.DomainConfig, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Matches(d, r.Host) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("404 - Not Found"))
+	})
+}
@@ -0,0 +1,12 @@
+// Package dynamic defines the routing configuration shape that
+// providers produce and the Watcher merges, as opposed to the static,
+// file-only config.Config GhostGate starts with.
+package dynamic
+
+import "github.com/ghostkellz/ghostgate/internal/config"
+
+// Configuration is the set of virtual hosts contributed by a single
+// provider update.
+type Configuration struct {
+	Domains []config.DomainConfig
+}
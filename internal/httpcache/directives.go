@@ -0,0 +1,74 @@
+package httpcache
+
+import (
+	"strconv"
+	"strings"
+)
+
+// directives is a parsed Cache-Control header, covering the request and
+// response directives GhostGate's cache understands (RFC 7234 §5.2).
+type directives struct {
+	noStore              bool
+	noCache              bool
+	private              bool
+	maxAge               *int
+	sMaxAge              *int
+	staleWhileRevalidate *int
+	staleIfError         *int
+}
+
+func parseCacheControl(header string) directives {
+	var d directives
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch name {
+		case "no-store":
+			d.noStore = true
+		case "no-cache":
+			d.noCache = true
+		case "private":
+			d.private = true
+		case "max-age":
+			d.maxAge = parseSeconds(value)
+		case "s-maxage":
+			d.sMaxAge = parseSeconds(value)
+		case "stale-while-revalidate":
+			d.staleWhileRevalidate = parseSeconds(value)
+		case "stale-if-error":
+			d.staleIfError = parseSeconds(value)
+		}
+	}
+	return d
+}
+
+func parseSeconds(value string) *int {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// cacheableStatus reports whether status is a response code RFC 7234
+// allows a shared cache to store by default.
+func cacheableStatus(status int) bool {
+	switch status {
+	case 200, 203, 204, 206, 300, 301, 404, 405, 410, 414, 501:
+		return true
+	default:
+		return false
+	}
+}
+
+// cacheableMethod reports whether method is safe and idempotent enough
+// to cache.
+func cacheableMethod(method string) bool {
+	return method == "GET" || method == "HEAD"
+}
@@ -0,0 +1,116 @@
+package httpcache
+
+import "testing"
+
+func intPtr(n int) *int { return &n }
+
+func TestParseCacheControl(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   directives
+	}{
+		{
+			name:   "empty header",
+			header: "",
+			want:   directives{},
+		},
+		{
+			name:   "max-age",
+			header: "max-age=120",
+			want:   directives{maxAge: intPtr(120)},
+		},
+		{
+			name:   "multiple directives with whitespace",
+			header: "no-cache,  max-age=60 , private",
+			want:   directives{noCache: true, private: true, maxAge: intPtr(60)},
+		},
+		{
+			name:   "quoted value",
+			header: `max-age="30"`,
+			want:   directives{maxAge: intPtr(30)},
+		},
+		{
+			name:   "s-maxage overrides for shared caches",
+			header: "max-age=60, s-maxage=300",
+			want:   directives{maxAge: intPtr(60), sMaxAge: intPtr(300)},
+		},
+		{
+			name:   "stale-while-revalidate and stale-if-error",
+			header: "max-age=60, stale-while-revalidate=30, stale-if-error=86400",
+			want:   directives{maxAge: intPtr(60), staleWhileRevalidate: intPtr(30), staleIfError: intPtr(86400)},
+		},
+		{
+			name:   "no-store wins regardless of case",
+			header: "NO-STORE",
+			want:   directives{noStore: true},
+		},
+		{
+			name:   "unparseable max-age is ignored",
+			header: "max-age=forever",
+			want:   directives{},
+		},
+		{
+			name:   "unknown directive is ignored",
+			header: "community=UCI",
+			want:   directives{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCacheControl(tt.header)
+			if !directivesEqual(got, tt.want) {
+				t.Errorf("parseCacheControl(%q) = %+v, want %+v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func directivesEqual(a, b directives) bool {
+	return a.noStore == b.noStore &&
+		a.noCache == b.noCache &&
+		a.private == b.private &&
+		intPtrEqual(a.maxAge, b.maxAge) &&
+		intPtrEqual(a.sMaxAge, b.sMaxAge) &&
+		intPtrEqual(a.staleWhileRevalidate, b.staleWhileRevalidate) &&
+		intPtrEqual(a.staleIfError, b.staleIfError)
+}
+
+func intPtrEqual(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func TestCacheableStatus(t *testing.T) {
+	cases := map[int]bool{
+		200: true,
+		204: true,
+		301: true,
+		404: true,
+		500: false,
+		502: false,
+		201: false,
+	}
+	for status, want := range cases {
+		if got := cacheableStatus(status); got != want {
+			t.Errorf("cacheableStatus(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestCacheableMethod(t *testing.T) {
+	cases := map[string]bool{
+		"GET":    true,
+		"HEAD":   true,
+		"POST":   false,
+		"DELETE": false,
+	}
+	for method, want := range cases {
+		if got := cacheableMethod(method); got != want {
+			t.Errorf("cacheableMethod(%q) = %v, want %v", method, got, want)
+		}
+	}
+}
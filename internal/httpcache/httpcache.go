@@ -0,0 +1,274 @@
+// Package httpcache is an RFC 7234-aware HTTP response cache, replacing
+// the naive "cache every URL for 5 minutes" middleware that used to live
+// in internal/middleware. It honors request/response Cache-Control,
+// Vary, Expires, Pragma, and Authorization, supports conditional
+// revalidation against stored ETag/Last-Modified, coalesces concurrent
+// misses, and evicts with a per-domain size-bounded LRU.
+package httpcache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/ghostkellz/ghostgate/internal/metrics"
+)
+
+// DefaultMaxBytesPerDomain bounds how much response body GhostGate will
+// cache for a single domain before evicting the least recently used
+// entries.
+const DefaultMaxBytesPerDomain = 64 * 1024 * 1024
+
+// Cache is an RFC 7234-aware shared HTTP cache, partitioned per domain so
+// one busy domain cannot evict another's entries.
+type Cache struct {
+	maxBytesPerDomain int64
+
+	mu      sync.Mutex
+	domains map[string]*domainLRU
+
+	inflightMu sync.Mutex
+	inflight   map[string]chan struct{}
+}
+
+// New builds a Cache whose per-domain shards hold up to maxBytesPerDomain
+// bytes of response bodies. A value <= 0 uses DefaultMaxBytesPerDomain.
+func New(maxBytesPerDomain int64) *Cache {
+	if maxBytesPerDomain <= 0 {
+		maxBytesPerDomain = DefaultMaxBytesPerDomain
+	}
+	return &Cache{
+		maxBytesPerDomain: maxBytesPerDomain,
+		domains:           make(map[string]*domainLRU),
+		inflight:          make(map[string]chan struct{}),
+	}
+}
+
+func (c *Cache) shard(domain string) *domainLRU {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.domains[domain]
+	if !ok {
+		d = newDomainLRU(c.maxBytesPerDomain)
+		c.domains[domain] = d
+	}
+	return d
+}
+
+// Middleware wraps next with caching for domain. Only enable this for
+// routes that opt in (ProxyRoute.Cache) — caching every response
+// unconditionally can serve stale or private data to the wrong client.
+func (c *Cache) Middleware(domain string, next http.Handler) http.Handler {
+	shard := c.shard(domain)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !cacheableMethod(r.Method) || r.Header.Get("Authorization") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		reqCC := parseCacheControl(r.Header.Get("Cache-Control"))
+		if reqCC.noStore {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.Method + " " + r.URL.String()
+		match := shard.find(key, r)
+
+		if match != nil {
+			age := time.Now().Unix() - match.storedAt
+			if age < int64(match.maxAge) && !reqCC.noCache {
+				writeEntry(w, match, "HIT")
+				metrics.CacheResults.WithLabelValues(domain, "hit").Inc()
+				return
+			}
+			if age < int64(match.maxAge+match.staleWhileRevalidate) && !reqCC.noCache {
+				writeEntry(w, match, "HIT")
+				metrics.CacheResults.WithLabelValues(domain, "hit").Inc()
+				go c.refresh(domain, shard, key, detachRequest(r), next, match)
+				return
+			}
+		}
+		metrics.CacheResults.WithLabelValues(domain, "miss").Inc()
+
+		status, newEntry, rec := c.fetch(domain, shard, key, r, next, match)
+		switch {
+		case newEntry != nil && status == "REVALIDATED":
+			writeEntry(w, newEntry, "REVALIDATED")
+		case newEntry != nil:
+			writeEntry(w, newEntry, "MISS")
+		case match != nil && rec.Code >= 500 && age(match) < int64(match.maxAge+match.staleIfError):
+			writeEntry(w, match, "HIT")
+		default:
+			relay(w, rec, "MISS")
+		}
+	})
+}
+
+func age(e *entry) int64 {
+	return time.Now().Unix() - e.storedAt
+}
+
+func cloneRequest(r *http.Request) *http.Request {
+	return r.Clone(r.Context())
+}
+
+// detachRequest clones r with a fresh, independent context instead of
+// r.Context(), which net/http cancels the instant the inbound
+// ServeHTTP call returns. The stale-while-revalidate background refresh
+// runs after the handler chain has already returned, so revalidating
+// with r.Context() would race its own cancellation and fail with
+// "context canceled" before the round-trip completes.
+func detachRequest(r *http.Request) *http.Request {
+	return r.Clone(context.Background())
+}
+
+// refresh revalidates a stale-while-revalidate entry in the background.
+func (c *Cache) refresh(domain string, shard *domainLRU, key string, r *http.Request, next http.Handler, match *entry) {
+	c.fetch(domain, shard, key, r, next, match)
+}
+
+// fetch runs next (single-flighted per key), optionally with conditional
+// headers from match, and stores a new cacheable entry. It returns the
+// status ("MISS" or "REVALIDATED"), the resulting entry (nil if the
+// response turned out not to be cacheable), and the raw recorder so
+// callers can relay an uncacheable response verbatim.
+func (c *Cache) fetch(domain string, shard *domainLRU, key string, r *http.Request, next http.Handler, match *entry) (string, *entry, *httptest.ResponseRecorder) {
+	flightKey := domain + "|" + key
+	for {
+		c.inflightMu.Lock()
+		ch, owned := c.inflight[flightKey]
+		if owned {
+			c.inflightMu.Unlock()
+			<-ch
+			if e := shard.find(key, r); e != nil {
+				return "MISS", e, nil
+			}
+			continue // the prior fetch wasn't cacheable; try to become the owner ourselves
+		}
+		ch = make(chan struct{})
+		c.inflight[flightKey] = ch
+		c.inflightMu.Unlock()
+		defer func() {
+			c.inflightMu.Lock()
+			delete(c.inflight, flightKey)
+			c.inflightMu.Unlock()
+			close(ch)
+		}()
+		break
+	}
+
+	req := r
+	if match != nil {
+		req = cloneRequest(r)
+		if match.etag != "" {
+			req.Header.Set("If-None-Match", match.etag)
+		}
+		if match.lastModified != "" {
+			req.Header.Set("If-Modified-Since", match.lastModified)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	next.ServeHTTP(rec, req)
+
+	if match != nil && rec.Code == http.StatusNotModified {
+		match.storedAt = time.Now().Unix()
+		shard.set(key, match)
+		return "REVALIDATED", match, rec
+	}
+
+	newEntry, cacheable := buildEntry(key, rec, req)
+	if !cacheable {
+		return "MISS", nil, rec
+	}
+	shard.set(key, newEntry)
+	return "MISS", newEntry, rec
+}
+
+func buildEntry(key string, rec *httptest.ResponseRecorder, r *http.Request) (*entry, bool) {
+	if !cacheableStatus(rec.Code) {
+		return nil, false
+	}
+	respCC := parseCacheControl(rec.Header().Get("Cache-Control"))
+	if respCC.noStore || respCC.private {
+		return nil, false
+	}
+	if pragma := rec.Header().Get("Pragma"); respCC.maxAge == nil && pragma == "no-cache" {
+		zero := 0
+		respCC.maxAge = &zero
+	}
+
+	maxAge := 0
+	switch {
+	case respCC.sMaxAge != nil:
+		maxAge = *respCC.sMaxAge
+	case respCC.maxAge != nil:
+		maxAge = *respCC.maxAge
+	case rec.Header().Get("Expires") != "":
+		if t, err := http.ParseTime(rec.Header().Get("Expires")); err == nil {
+			if d := int(time.Until(t).Seconds()); d > 0 {
+				maxAge = d
+			}
+		}
+	}
+
+	swr := 0
+	if respCC.staleWhileRevalidate != nil {
+		swr = *respCC.staleWhileRevalidate
+	}
+	sie := 0
+	if respCC.staleIfError != nil {
+		sie = *respCC.staleIfError
+	}
+
+	header := make(http.Header, len(rec.Header()))
+	for k, v := range rec.Header() {
+		header[k] = append([]string(nil), v...)
+	}
+
+	varyOn := rec.Header().Values("Vary")
+	varyValues := make(map[string]string, len(varyOn))
+	for _, name := range varyOn {
+		varyValues[name] = r.Header.Get(name)
+	}
+
+	return &entry{
+		key:                  key,
+		status:               rec.Code,
+		header:               header,
+		body:                 append([]byte(nil), rec.Body.Bytes()...),
+		etag:                 rec.Header().Get("ETag"),
+		lastModified:         rec.Header().Get("Last-Modified"),
+		storedAt:             time.Now().Unix(),
+		maxAge:               maxAge,
+		staleWhileRevalidate: swr,
+		staleIfError:         sie,
+		varyOn:               varyOn,
+		varyValues:           varyValues,
+	}, true
+}
+
+func writeEntry(w http.ResponseWriter, e *entry, cacheStatus string) {
+	for k, v := range e.header {
+		w.Header()[k] = append([]string(nil), v...)
+	}
+	w.Header().Set("X-Cache", cacheStatus)
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.WriteHeader(e.status)
+	w.Write(e.body)
+}
+
+func relay(w http.ResponseWriter, rec *httptest.ResponseRecorder, cacheStatus string) {
+	if rec == nil {
+		return
+	}
+	for k, v := range rec.Header() {
+		w.Header()[k] = append([]string(nil), v...)
+	}
+	w.Header().Set("X-Cache", cacheStatus)
+	w.WriteHeader(rec.Code)
+	w.Write(rec.Body.Bytes())
+}
@@ -0,0 +1,74 @@
+package httpcache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestBackgroundRefreshGetsALiveContext reproduces the stale-while-
+// revalidate background refresh path end to end through a real
+// http.Server (not a recorder called in-process), so the inbound
+// request's context is actually canceled once its ServeHTTP call
+// returns — the same way it is in production. Before the fix, the
+// background goroutine reused that request (via cloneRequest, which
+// preserves r.Context()), so by the time it ran the context was already
+// canceled; a real upstream RoundTrip checks ctx.Done() and fails with
+// "context canceled", exactly like an unreachable backend.
+func TestBackgroundRefreshGetsALiveContext(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	refreshed := make(chan error, 1)
+
+	backend := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+
+		w.Header().Set("Cache-Control", "max-age=0, stale-while-revalidate=30")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+
+		if n == 2 {
+			// This is the background SWR refresh's request, made after
+			// the first request's handler already returned. If it
+			// inherited that finished request's context, it would
+			// already be canceled here.
+			refreshed <- r.Context().Err()
+		}
+	})
+
+	c := New(0)
+	srv := httptest.NewServer(c.Middleware("example.com", backend))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	resp.Body.Close()
+
+	// Give the first request's context time to actually finish
+	// canceling server-side before the second request's background
+	// refresh fires, so this test would catch the bug it targets.
+	time.Sleep(50 * time.Millisecond)
+
+	resp, err = http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("second request: %v", err)
+	}
+	resp.Body.Close()
+
+	select {
+	case err := <-refreshed:
+		if err == context.Canceled {
+			t.Fatalf("background refresh ran with an already-canceled context")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("background refresh never ran")
+	}
+}
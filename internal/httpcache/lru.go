@@ -0,0 +1,155 @@
+package httpcache
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+)
+
+// entry is a single stored response variant.
+type entry struct {
+	key          string
+	status       int
+	header       http.Header
+	body         []byte
+	etag         string
+	lastModified string
+	storedAt     int64 // unix seconds
+	maxAge       int   // seconds of freshness from storedAt
+
+	staleWhileRevalidate int
+	staleIfError         int
+
+	// varyOn lists the request header names this variant was selected by
+	// (the response's Vary header at the time it was stored), along with
+	// the request header values it was stored under.
+	varyOn     []string
+	varyValues map[string]string
+}
+
+func (e *entry) size() int64 {
+	n := int64(len(e.body)) + int64(len(e.key))
+	for k, vs := range e.header {
+		n += int64(len(k))
+		for _, v := range vs {
+			n += int64(len(v))
+		}
+	}
+	return n
+}
+
+// matchesVary reports whether r carries the same values for e's Vary
+// header names as when e was stored.
+func (e *entry) matchesVary(r *http.Request) bool {
+	for _, name := range e.varyOn {
+		if r.Header.Get(name) != e.varyValues[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// bucket holds every cached variant for one cache key (method + URL),
+// distinguished by the values of any Vary'd request headers.
+type bucket struct {
+	variants []*entry
+}
+
+// domainLRU is a size-bounded, least-recently-used cache of buckets for a
+// single domain.
+type domainLRU struct {
+	mu       sync.Mutex
+	maxBytes int64
+	curBytes int64
+	items    map[string]*list.Element // key -> its element in order
+	order    *list.List               // front = most recently used; Value is bucketItem
+}
+
+type bucketItem struct {
+	key    string
+	bucket *bucket
+}
+
+func newDomainLRU(maxBytes int64) *domainLRU {
+	return &domainLRU{
+		maxBytes: maxBytes,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// find returns the variant matching r under key, if any, promoting it to
+// most-recently-used.
+func (c *domainLRU) find(key string, r *http.Request) *entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	b := el.Value.(bucketItem).bucket
+	for _, e := range b.variants {
+		if e.matchesVary(r) {
+			c.order.MoveToFront(el)
+			return e
+		}
+	}
+	return nil
+}
+
+// set stores e (whose varyValues must already be populated, see
+// buildEntry) as a variant under key.
+func (c *domainLRU) set(key string, e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		b := &bucket{variants: []*entry{e}}
+		el = c.order.PushFront(bucketItem{key: key, bucket: b})
+		c.items[key] = el
+		c.curBytes += e.size()
+	} else {
+		b := el.Value.(bucketItem).bucket
+		replaced := false
+		for i, existing := range b.variants {
+			if sameVaryValues(existing, e) {
+				c.curBytes -= existing.size()
+				b.variants[i] = e
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			b.variants = append(b.variants, e)
+		}
+		c.curBytes += e.size()
+		c.order.MoveToFront(el)
+	}
+
+	for c.curBytes > c.maxBytes {
+		back := c.order.Back()
+		if back == nil {
+			break
+		}
+		old := back.Value.(bucketItem)
+		for _, e := range old.bucket.variants {
+			c.curBytes -= e.size()
+		}
+		c.order.Remove(back)
+		delete(c.items, old.key)
+	}
+}
+
+func sameVaryValues(a, b *entry) bool {
+	if len(a.varyOn) != len(b.varyOn) {
+		return false
+	}
+	for _, name := range a.varyOn {
+		if a.varyValues[name] != b.varyValues[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/syslog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghostkellz/ghostgate/internal/config"
+)
+
+// AccessEntry is one logged request, carrying the fields the text, json,
+// common, and combined access-log formats all draw from.
+type AccessEntry struct {
+	Time      time.Time
+	Domain    string
+	RemoteIP  string
+	Method    string
+	Path      string
+	Proto     string
+	Status    int
+	BytesOut  int64
+	Duration  time.Duration
+	Upstream  string
+	RequestID string
+	Referer   string
+	UserAgent string
+}
+
+// AccessLogger writes AccessEntry values to a domain's configured
+// destination in its configured format. The zero value is not usable;
+// build one with NewAccessLogger.
+type AccessLogger struct {
+	format string
+	mu     sync.Mutex
+	out    io.Writer
+	closer io.Closer
+}
+
+// NewAccessLogger builds the AccessLogger for a domain's access_log
+// config. An empty cfg.Output defaults to stdout; an empty cfg.Format
+// defaults to "text".
+func NewAccessLogger(cfg config.AccessLogConfig) (*AccessLogger, error) {
+	format := strings.ToLower(cfg.Format)
+	if format == "" {
+		format = "text"
+	}
+
+	var out io.Writer
+	var closer io.Closer
+	switch strings.ToLower(cfg.Output) {
+	case "", "stdout":
+		out = os.Stdout
+	case "file":
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("access_log: output=file requires path")
+		}
+		f, err := newRotatingFile(cfg.Path, rotateMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		out, closer = f, f
+	case "syslog":
+		w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_LOCAL0, "ghostgate")
+		if err != nil {
+			return nil, err
+		}
+		out, closer = w, w
+	default:
+		return nil, fmt.Errorf("access_log: unknown output %q", cfg.Output)
+	}
+
+	return &AccessLogger{format: format, out: out, closer: closer}, nil
+}
+
+// Close releases the underlying destination (a no-op for stdout).
+func (a *AccessLogger) Close() error {
+	if a.closer != nil {
+		return a.closer.Close()
+	}
+	return nil
+}
+
+// Log formats e per the logger's configured format and writes it as one
+// line to the destination.
+func (a *AccessLogger) Log(e AccessEntry) {
+	var line string
+	switch a.format {
+	case "json":
+		line = jsonLine(e)
+	case "common":
+		line = commonLogLine(e)
+	case "combined":
+		line = combinedLogLine(e)
+	default:
+		line = textLine(e)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	fmt.Fprintln(a.out, line)
+}
+
+type jsonAccessRecord struct {
+	Time       string `json:"time"`
+	Domain     string `json:"domain"`
+	RemoteIP   string `json:"remote_ip"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	BytesOut   int64  `json:"bytes_out"`
+	DurationMS int64  `json:"duration_ms"`
+	Upstream   string `json:"upstream,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+}
+
+func jsonLine(e AccessEntry) string {
+	b, err := json.Marshal(jsonAccessRecord{
+		Time:       e.Time.Format(time.RFC3339),
+		Domain:     e.Domain,
+		RemoteIP:   e.RemoteIP,
+		Method:     e.Method,
+		Path:       e.Path,
+		Status:     e.Status,
+		BytesOut:   e.BytesOut,
+		DurationMS: e.Duration.Milliseconds(),
+		Upstream:   e.Upstream,
+		RequestID:  e.RequestID,
+	})
+	if err != nil {
+		return fmt.Sprintf(`{"error":"failed to marshal access log entry: %s"}`, err)
+	}
+	return string(b)
+}
+
+func textLine(e AccessEntry) string {
+	return fmt.Sprintf(
+		"time=%s domain=%s method=%s path=%s status=%d bytes_out=%d duration_ms=%d upstream=%s request_id=%s remote_ip=%s",
+		e.Time.Format(time.RFC3339), e.Domain, e.Method, e.Path, e.Status, e.BytesOut,
+		e.Duration.Milliseconds(), orDash(e.Upstream), orDash(e.RequestID), orDash(e.RemoteIP),
+	)
+}
+
+// commonLogLine formats e per the Apache/NGINX Common Log Format:
+// host ident authuser [date] "request line" status bytes
+func commonLogLine(e AccessEntry) string {
+	return fmt.Sprintf(`%s - - [%s] "%s %s %s" %d %d`,
+		orDash(e.RemoteIP), e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, e.Path, e.Proto, e.Status, e.BytesOut)
+}
+
+// combinedLogLine is commonLogLine plus the Referer and User-Agent
+// headers, as served by Apache's "combined" format.
+func combinedLogLine(e AccessEntry) string {
+	return fmt.Sprintf(`%s - - [%s] "%s %s %s" %d %d "%s" "%s"`,
+		orDash(e.RemoteIP), e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, e.Path, e.Proto, e.Status, e.BytesOut, orDash(e.Referer), orDash(e.UserAgent))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
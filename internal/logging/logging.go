@@ -0,0 +1,114 @@
+// Package logging replaces GhostGate's old setupLogger/jsonLogWriter
+// with log/slog: real level filtering, text/json process-wide logging,
+// and per-domain access logs in text, json, common (CLF), or combined
+// format, written to stdout, a rotated file, or syslog.
+package logging
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Setup configures the process-wide slog default logger from level,
+// format ("text" or "json"), and timeFormat (a time.Layout reference
+// timestamp; time.RFC3339 if empty). It also redirects the standard
+// library "log" package — still used for most of GhostGate's internal
+// logging — through the same handler, so every log.Printf call gets real
+// level filtering and a consistent format instead of the old logger's
+// discard-on-error and double-written JSON.
+func Setup(level, format, timeFormat string) *slog.Logger {
+	if timeFormat == "" {
+		timeFormat = time.RFC3339
+	}
+	slogLevel := parseLevel(level)
+	opts := &slog.HandlerOptions{
+		Level: slogLevel,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				a.Value = slog.StringValue(a.Value.Time().Format(timeFormat))
+			}
+			return a
+		},
+	}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+
+	log.SetFlags(0)
+	log.SetOutput(&legacyLogWriter{handler: handler})
+	return logger
+}
+
+// legacyLogWriter adapts the stdlib "log" package, still used for most of
+// GhostGate's internal logging, to a slog.Handler. GhostGate's log.Printf
+// call sites conventionally prefix their message with "[LEVEL]" (e.g.
+// "[WARN] certs: ..."); Write parses that prefix to pick the slog.Level
+// the line is actually reported at and checks it against the handler's
+// configured minimum, instead of logging every line at one fixed level
+// regardless of its text. Lines without a recognized prefix are treated
+// as Info, matching their historical always-on behavior.
+type legacyLogWriter struct {
+	handler slog.Handler
+}
+
+func (w *legacyLogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	level, msg := splitLevelPrefix(msg)
+
+	ctx := context.Background()
+	if w.handler.Enabled(ctx, level) {
+		record := slog.NewRecord(time.Now(), level, msg, 0)
+		_ = w.handler.Handle(ctx, record)
+	}
+	return len(p), nil
+}
+
+// splitLevelPrefix extracts a leading "[LEVEL]" tag from msg, returning
+// the corresponding slog.Level and the message with the tag stripped. If
+// msg has no recognized tag, it is returned unchanged at slog.LevelInfo.
+func splitLevelPrefix(msg string) (slog.Level, string) {
+	if !strings.HasPrefix(msg, "[") {
+		return slog.LevelInfo, msg
+	}
+	end := strings.Index(msg, "]")
+	if end < 0 {
+		return slog.LevelInfo, msg
+	}
+	tag, body := msg[1:end], strings.TrimPrefix(msg[end+1:], " ")
+	switch strings.ToUpper(tag) {
+	case "DEBUG":
+		return slog.LevelDebug, body
+	case "INFO":
+		return slog.LevelInfo, body
+	case "WARN", "WARNING":
+		return slog.LevelWarn, body
+	case "ERROR", "ERR", "FATAL":
+		return slog.LevelError, body
+	default:
+		return slog.LevelInfo, msg
+	}
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
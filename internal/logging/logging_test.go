@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestLegacyLogWriterFiltersByPrefix verifies that log.Printf calls
+// carrying a "[LEVEL]" prefix are actually filtered against the
+// configured level, not just stamped with it and passed through
+// regardless of severity.
+func TestLegacyLogWriterFiltersByPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelError})
+	log.SetFlags(0)
+	log.SetOutput(&legacyLogWriter{handler: handler})
+
+	log.Printf("[INFO] should be dropped")
+	log.Printf("[WARN] should also be dropped")
+	log.Printf("[ERROR] should appear")
+
+	out := buf.String()
+	if strings.Contains(out, "should be dropped") || strings.Contains(out, "should also be dropped") {
+		t.Fatalf("level=error configuration let an INFO/WARN line through: %q", out)
+	}
+	if !strings.Contains(out, "should appear") || !strings.Contains(out, "level=ERROR") {
+		t.Fatalf("expected the ERROR line tagged level=ERROR, got: %q", out)
+	}
+}
+
+func TestSplitLevelPrefix(t *testing.T) {
+	cases := []struct {
+		in    string
+		level slog.Level
+		body  string
+	}{
+		{"[DEBUG] x", slog.LevelDebug, "x"},
+		{"[INFO] x", slog.LevelInfo, "x"},
+		{"[WARN] x", slog.LevelWarn, "x"},
+		{"[WARNING] x", slog.LevelWarn, "x"},
+		{"[ERROR] x", slog.LevelError, "x"},
+		{"no prefix here", slog.LevelInfo, "no prefix here"},
+	}
+	for _, c := range cases {
+		level, body := splitLevelPrefix(c.in)
+		if level != c.level || body != c.body {
+			t.Errorf("splitLevelPrefix(%q) = (%v, %q), want (%v, %q)", c.in, level, body, c.level, c.body)
+		}
+	}
+}
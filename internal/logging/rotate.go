@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// rotateMaxBytes is the size at which a file-backed AccessLogger rotates
+// the current log file out to a timestamped backup.
+const rotateMaxBytes = 100 * 1024 * 1024
+
+// rotatingFile is an io.WriteCloser over an append-mode file that renames
+// itself out of the way once it exceeds maxBytes, then reopens a fresh
+// file at the original path.
+type rotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	file     *os.File
+	size     int64
+}
+
+func newRotatingFile(path string, maxBytes int64) (*rotatingFile, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &rotatingFile{path: path, maxBytes: maxBytes, file: f, size: info.Size()}, nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	backup := r.path + "." + time.Now().Format("20060102-150405")
+	if err := os.Rename(r.path, backup); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.size = 0
+	return nil
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
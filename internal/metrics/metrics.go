@@ -0,0 +1,58 @@
+// Package metrics holds the Prometheus collectors GhostGate exposes on
+// /metrics, named in the style of mox's mox_httpserver_request_duration_seconds.
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// RequestDuration buckets request latency from 1ms to 120s, labeled by
+	// the virtual host, route, method, status, and protocol involved.
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ghostgate_request_duration_seconds",
+		Help:    "HTTP request duration in seconds.",
+		Buckets: prometheus.ExponentialBucketsRange(0.001, 120, 20),
+	}, []string{"domain", "route", "method", "status", "proto"})
+
+	// CacheResults counts response cache hits and misses per domain.
+	CacheResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ghostgate_cache_results_total",
+		Help: "Response cache hits and misses, labeled by domain and result (hit|miss).",
+	}, []string{"domain", "result"})
+
+	// RateLimitRejections counts requests rejected with 429 per domain/route.
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ghostgate_rate_limit_rejections_total",
+		Help: "Requests rejected by a route's rate limiter.",
+	}, []string{"domain", "route"})
+
+	// UpstreamErrors counts reverse-proxy dial/response errors per domain/route.
+	UpstreamErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ghostgate_upstream_errors_total",
+		Help: "Reverse proxy errors reaching the upstream backend.",
+	}, []string{"domain", "route"})
+
+	// CertsLoaded is the number of certificates currently held by the SNI
+	// certificate store.
+	CertsLoaded = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ghostgate_certs_loaded",
+		Help: "Number of certificates currently loaded in the certificate store.",
+	})
+
+	// CertExpiryDays is the number of days until a loaded certificate
+	// expires, labeled by its primary name.
+	CertExpiryDays = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ghostgate_cert_expiry_days",
+		Help: "Days until a loaded certificate expires, labeled by domain.",
+	}, []string{"domain"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		RequestDuration,
+		CacheResults,
+		RateLimitRejections,
+		UpstreamErrors,
+		CertsLoaded,
+		CertExpiryDays,
+	)
+}
@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressMinBytes is the smallest Content-Length CompressMiddleware will
+// bother compressing; below this the framing overhead isn't worth it.
+const compressMinBytes = 256
+
+// incompressibleTypes are content-type prefixes that are already
+// compressed (or otherwise not worth recompressing), so CompressMiddleware
+// leaves them alone.
+var incompressibleTypes = []string{
+	"image/", "video/", "audio/",
+	"application/zip", "application/gzip", "application/x-gzip",
+	"application/x-7z-compressed", "application/x-rar-compressed",
+	"application/x-bzip", "application/x-bzip2",
+	"application/zstd", "application/x-brotli",
+	"font/woff", "font/woff2",
+}
+
+func isIncompressible(contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+	ct, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		ct = contentType
+	}
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(ct, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompressMiddleware negotiates br, zstd, or gzip compression from the
+// request's Accept-Encoding header (honoring q-values), replacing the old
+// gzip-only wrapper. It skips responses that are already encoded, whose
+// Content-Type is already compressed, or whose Content-Length is too small
+// to be worth it, and passes through Flusher/Hijacker/ReaderFrom so
+// streaming responses and WebSocket upgrades keep working.
+func CompressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := pickEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// pickEncoding returns the best encoding CompressMiddleware supports
+// ("br", "zstd", or "gzip") that header's q-values allow, or "" if none do.
+func pickEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+	type candidate struct {
+		name string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{name, q})
+		}
+	}
+	// Among equal q-values, prefer br over zstd over gzip.
+	rank := map[string]int{"br": 3, "zstd": 2, "gzip": 1}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].q != candidates[j].q {
+			return candidates[i].q > candidates[j].q
+		}
+		return rank[candidates[i].name] > rank[candidates[j].name]
+	})
+	for _, c := range candidates {
+		if _, ok := rank[c.name]; ok {
+			return c.name
+		}
+	}
+	return ""
+}
+
+func newEncoder(encoding string, w io.Writer) io.WriteCloser {
+	switch encoding {
+	case "br":
+		return brotli.NewWriter(w)
+	case "zstd":
+		if enc, err := zstd.NewWriter(w); err == nil {
+			return enc
+		}
+		return gzip.NewWriter(w)
+	default:
+		return gzip.NewWriter(w)
+	}
+}
+
+// compressWriter wraps an http.ResponseWriter, compressing the body with
+// the negotiated encoding once it decides (at WriteHeader time) that the
+// response is worth compressing.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+
+	headerWritten bool
+	active        bool
+	enc           io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.headerWritten {
+		return
+	}
+	w.headerWritten = true
+
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || isIncompressible(h.Get("Content-Type")) {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if cl := h.Get("Content-Length"); cl != "" {
+		if n, err := strconv.Atoi(cl); err == nil && n < compressMinBytes {
+			w.ResponseWriter.WriteHeader(status)
+			return
+		}
+	}
+
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", w.encoding)
+	h.Add("Vary", "Accept-Encoding")
+	w.active = true
+	w.enc = newEncoder(w.encoding, w.ResponseWriter)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.headerWritten {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.active {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Close flushes and closes the active encoder, if any. CompressMiddleware
+// defers this once per request.
+func (w *compressWriter) Close() error {
+	if w.enc != nil {
+		return w.enc.Close()
+	}
+	return nil
+}
+
+// Flush implements http.Flusher, flushing any buffered compressed bytes
+// before flushing the underlying connection.
+func (w *compressWriter) Flush() {
+	if w.enc != nil {
+		if f, ok := w.enc.(interface{ Flush() error }); ok {
+			f.Flush()
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker so WebSocket upgrades bypass compression
+// entirely.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("underlying ResponseWriter does not support http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+// onlyWriter hides any io.ReaderFrom implementation on w so io.Copy cannot
+// call back into compressWriter.ReadFrom and recurse.
+type onlyWriter struct{ io.Writer }
+
+// ReadFrom implements io.ReaderFrom, delegating straight to the underlying
+// ResponseWriter when no compression is active (so e.g. reverse-proxy
+// response copies keep any sendfile-style fast path) and falling back to a
+// plain copy through Write when compressing.
+func (w *compressWriter) ReadFrom(src io.Reader) (int64, error) {
+	if !w.headerWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.active {
+		if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+			return rf.ReadFrom(src)
+		}
+	}
+	return io.Copy(onlyWriter{w}, src)
+}
@@ -0,0 +1,102 @@
+// Package middleware holds the HTTP middleware chain GhostGate wraps
+// around domain handlers: logging, security headers/ACLs, and
+// compression.
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ghostkellz/ghostgate/internal/logging"
+	"github.com/ghostkellz/ghostgate/internal/metrics"
+)
+
+// Logging writes one access-log line per request via accessLogger (in
+// whatever format/destination the domain configured) and records it in
+// the ghostgate_request_duration_seconds histogram. upstream identifies
+// what served the request (a route's backend URL, or "static:<dir>") for
+// the access log's upstream field.
+func Logging(domain, route, upstream string, accessLogger *logging.AccessLogger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		duration := time.Since(start)
+
+		if accessLogger != nil {
+			accessLogger.Log(logging.AccessEntry{
+				Time:      start,
+				Domain:    domain,
+				RemoteIP:  r.RemoteAddr,
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Proto:     r.Proto,
+				Status:    rec.status,
+				BytesOut:  rec.bytes,
+				Duration:  duration,
+				Upstream:  upstream,
+				RequestID: RequestIDFromContext(r.Context()),
+				Referer:   r.Referer(),
+				UserAgent: r.UserAgent(),
+			})
+		}
+		metrics.RequestDuration.WithLabelValues(domain, route, r.Method, strconv.Itoa(rec.status), r.Proto).
+			Observe(duration.Seconds())
+	})
+}
+
+// statusRecorder captures the status code and byte count a handler
+// wrote, defaulting to 200 if WriteHeader is never called.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher so SSE/long-poll handlers streaming
+// through Logging can still flush periodically.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker so WebSocket upgrades proxied through
+// Logging still work; httputil.ReverseProxy falls back to a 502 when its
+// ResponseWriter doesn't support this.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("underlying ResponseWriter does not support http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+// ReadFrom implements io.ReaderFrom, delegating to the underlying
+// ResponseWriter when it supports it (keeping any sendfile-style fast
+// path) while still counting bytes for the access log.
+func (r *statusRecorder) ReadFrom(src io.Reader) (int64, error) {
+	if rf, ok := r.ResponseWriter.(io.ReaderFrom); ok {
+		n, err := rf.ReadFrom(src)
+		r.bytes += n
+		return n, err
+	}
+	n, err := io.Copy(struct{ io.Writer }{r}, src)
+	return n, err
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// hijackableRecorder is an httptest.ResponseRecorder that also implements
+// http.Hijacker, the way a real net/http connection's ResponseWriter does.
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	server, _ := net.Pipe()
+	return server, bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server)), nil
+}
+
+func TestLoggingPreservesHijacker(t *testing.T) {
+	base := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+	handler := Logging("example.com", "/", "backend", nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Fatal("ResponseWriter passed through Logging does not implement http.Hijacker")
+		}
+		conn, _, err := hj.Hijack()
+		if err != nil {
+			t.Fatalf("Hijack: %v", err)
+		}
+		conn.Close()
+	}))
+
+	handler.ServeHTTP(base, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !base.hijacked {
+		t.Fatal("expected underlying ResponseWriter's Hijack to be called")
+	}
+}
+
+func TestStatusRecorderFlush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sr := &statusRecorder{ResponseWriter: rec, status: http.StatusOK}
+
+	// httptest.ResponseRecorder implements http.Flusher; this must not panic.
+	sr.Flush()
+	if !rec.Flushed {
+		t.Fatal("expected underlying ResponseWriter's Flush to be called")
+	}
+}
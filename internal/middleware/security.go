@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ghostkellz/ghostgate/internal/clientip"
+	"github.com/ghostkellz/ghostgate/internal/config"
+)
+
+// Security enforces per-domain IP allow/deny lists (CIDR-aware, via
+// net/netip) and sets HSTS/CSP response headers. ACL matching keys off
+// the client IP resolved by clientip.Resolver, which honors
+// TrustedProxies/RealIPHeader rather than trusting RemoteAddr directly
+// when the peer is a known reverse proxy.
+func Security(d config.DomainConfig, next http.Handler) http.Handler {
+	allow := clientip.ParsePrefixes(d.Domain, "allow_ips", d.AllowIPs)
+	deny := clientip.ParsePrefixes(d.Domain, "deny_ips", d.DenyIPs)
+	resolver := clientip.NewResolver(d.Domain, d.TrustedProxies, d.RealIPHeader)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := resolver.Resolve(r)
+		if len(allow) > 0 && (!ip.IsValid() || !clientip.ContainsIP(allow, ip)) {
+			http.Error(w, "403 - Forbidden", http.StatusForbidden)
+			return
+		}
+		if ip.IsValid() && clientip.ContainsIP(deny, ip) {
+			http.Error(w, "403 - Forbidden", http.StatusForbidden)
+			return
+		}
+		if d.HSTS {
+			w.Header().Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains; preload")
+		}
+		if d.CSP != "" {
+			w.Header().Set("Content-Security-Policy", d.CSP)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
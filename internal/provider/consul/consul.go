@@ -0,0 +1,130 @@
+// Package consul is a provider that discovers domains from a Consul KV
+// prefix, polling the Consul HTTP API (no Consul SDK dependency). Each
+// key under Prefix holds a YAML-encoded config.DomainConfig, e.g.
+// ghostgate/domains/example-com -> "domain: example.com\nproxy_routes: ...".
+package consul
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ghostkellz/ghostgate/internal/config"
+	"github.com/ghostkellz/ghostgate/internal/dynamic"
+	"github.com/ghostkellz/ghostgate/internal/provider"
+	"github.com/ghostkellz/ghostgate/internal/safe"
+)
+
+// Name is the provider name used in Message.ProviderName.
+const Name = "consul"
+
+// Provider polls a Consul agent's KV store.
+type Provider struct {
+	// Address is the Consul HTTP API base URL, e.g. "http://127.0.0.1:8500".
+	Address string
+	// Prefix is the KV prefix to watch, e.g. "ghostgate/domains".
+	Prefix string
+	// PollInterval controls how often the prefix is re-read. Defaults to 5s.
+	PollInterval time.Duration
+
+	client *http.Client
+}
+
+// New builds a consul Provider.
+func New(address, prefix string, pollInterval time.Duration) *Provider {
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	return &Provider{
+		Address:      strings.TrimRight(address, "/"),
+		Prefix:       strings.TrimLeft(prefix, "/"),
+		PollInterval: pollInterval,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type kvEntry struct {
+	Key   string
+	Value string // base64-encoded
+}
+
+func (p *Provider) poll() (*dynamic.Configuration, error) {
+	url := fmt.Sprintf("%s/v1/kv/%s?recurse=true", p.Address, p.Prefix)
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &dynamic.Configuration{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("consul provider: unexpected status %s", resp.Status)
+	}
+
+	var entries []kvEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	var domains []config.DomainConfig
+	for _, e := range entries {
+		raw, err := base64.StdEncoding.DecodeString(e.Value)
+		if err != nil {
+			log.Printf("[WARN] consul provider: key %s: invalid base64: %v", e.Key, err)
+			continue
+		}
+		var d config.DomainConfig
+		if err := yaml.Unmarshal(raw, &d); err != nil {
+			log.Printf("[WARN] consul provider: key %s: invalid domain config: %v", e.Key, err)
+			continue
+		}
+		if d.Domain != "" {
+			domains = append(domains, d)
+		}
+	}
+
+	return &dynamic.Configuration{Domains: domains}, nil
+}
+
+// Provide polls the Consul KV prefix every PollInterval and publishes a
+// Message after every successful poll, whether or not the configuration
+// changed. A failed initial poll
+// (e.g. the Consul agent isn't reachable yet) is logged, not returned,
+// so one unreachable provider can't take down Server.Run: discovery
+// simply starts empty and picks up keys once Consul answers.
+func (p *Provider) Provide(configurationChan chan<- provider.Message, pool *safe.Pool) error {
+	if cfg, err := p.poll(); err != nil {
+		log.Printf("[WARN] consul provider: initial poll failed: %v", err)
+	} else {
+		configurationChan <- provider.Message{ProviderName: Name, Configuration: cfg}
+	}
+
+	pool.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(p.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cfg, err := p.poll()
+				if err != nil {
+					log.Printf("[WARN] consul provider: poll failed: %v", err)
+					continue
+				}
+				configurationChan <- provider.Message{ProviderName: Name, Configuration: cfg}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package consul
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ghostkellz/ghostgate/internal/provider"
+	"github.com/ghostkellz/ghostgate/internal/safe"
+)
+
+func TestPollParsesKV(t *testing.T) {
+	good := base64.StdEncoding.EncodeToString([]byte("domain: a.example.com\nproxy_routes:\n  - path: /\n    backend: http://127.0.0.1:8080\n"))
+	bad := base64.StdEncoding.EncodeToString([]byte("not: [valid"))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"Key": "ghostgate/domains/a", "Value": "` + good + `"},
+			{"Key": "ghostgate/domains/b", "Value": "` + bad + `"}
+		]`))
+	}))
+	defer srv.Close()
+
+	p := New(srv.URL, "ghostgate/domains", time.Second)
+	cfg, err := p.poll()
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if len(cfg.Domains) != 1 || cfg.Domains[0].Domain != "a.example.com" {
+		t.Fatalf("expected exactly one valid domain, got %+v", cfg.Domains)
+	}
+}
+
+func TestPollTreatsNotFoundAsEmpty(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	p := New(srv.URL, "ghostgate/domains", time.Second)
+	cfg, err := p.poll()
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if len(cfg.Domains) != 0 {
+		t.Fatalf("expected no domains for an absent prefix, got %+v", cfg.Domains)
+	}
+}
+
+// TestProvideToleratesInitialPollFailure verifies that an unreachable
+// Consul agent at startup doesn't fail Provide (and so doesn't abort
+// Server.Run); it logs and keeps polling in the background instead.
+func TestProvideToleratesInitialPollFailure(t *testing.T) {
+	p := New("http://127.0.0.1:1", "ghostgate/domains", time.Hour)
+	pool := safe.NewPool(context.Background())
+	defer pool.Stop()
+
+	ch := make(chan provider.Message, 1)
+	if err := p.Provide(ch, pool); err != nil {
+		t.Fatalf("Provide returned an error for an unreachable endpoint: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package docker is a provider that discovers domains from running
+// container labels, polling the Docker Engine API directly over its Unix
+// socket so no separate Docker SDK dependency is required. Endpoint is
+// always dialed as a Unix socket path; TCP endpoints are not supported.
+//
+// Containers opt in with labels:
+//
+//	ghostgate.domain=example.com
+//	ghostgate.backend=http://127.0.0.1:8080
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ghostkellz/ghostgate/internal/config"
+	"github.com/ghostkellz/ghostgate/internal/dynamic"
+	"github.com/ghostkellz/ghostgate/internal/provider"
+	"github.com/ghostkellz/ghostgate/internal/safe"
+)
+
+// Name is the provider name used in Message.ProviderName.
+const Name = "docker"
+
+const (
+	domainLabel  = "ghostgate.domain"
+	backendLabel = "ghostgate.backend"
+)
+
+// Provider polls the Docker API for containers carrying ghostgate.* labels.
+type Provider struct {
+	// Endpoint is the Docker daemon socket, e.g. "/var/run/docker.sock".
+	// Defaults to "/var/run/docker.sock".
+	Endpoint string
+	// PollInterval controls how often the container list is re-fetched.
+	// Defaults to 5s.
+	PollInterval time.Duration
+
+	client *http.Client
+}
+
+// New builds a docker Provider.
+func New(endpoint string, pollInterval time.Duration) *Provider {
+	if endpoint == "" {
+		endpoint = "/var/run/docker.sock"
+	}
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	return &Provider{
+		Endpoint:     endpoint,
+		PollInterval: pollInterval,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", endpoint)
+				},
+			},
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+type containerSummary struct {
+	Labels map[string]string `json:"Labels"`
+}
+
+func (p *Provider) poll() (*dynamic.Configuration, error) {
+	resp, err := p.client.Get("http://docker/containers/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("docker provider: unexpected status %s", resp.Status)
+	}
+
+	var containers []containerSummary
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, err
+	}
+
+	var domains []config.DomainConfig
+	for _, c := range containers {
+		domainName := c.Labels[domainLabel]
+		backend := c.Labels[backendLabel]
+		if domainName == "" || backend == "" {
+			continue
+		}
+		domains = append(domains, config.DomainConfig{
+			Domain: domainName,
+			ProxyRoutes: []config.ProxyRoute{
+				{Path: "/", Backend: backend},
+			},
+		})
+	}
+
+	return &dynamic.Configuration{Domains: domains}, nil
+}
+
+// Provide polls the Docker API every PollInterval and publishes a Message
+// after every successful poll, whether or not the set of discovered
+// domains changed. A failed initial poll
+// (e.g. the Docker socket isn't reachable yet) is logged, not returned,
+// so one unreachable provider can't take down Server.Run: discovery
+// simply starts empty and picks up containers once Docker answers.
+func (p *Provider) Provide(configurationChan chan<- provider.Message, pool *safe.Pool) error {
+	if cfg, err := p.poll(); err != nil {
+		log.Printf("[WARN] docker provider: initial poll failed: %v", err)
+	} else {
+		configurationChan <- provider.Message{ProviderName: Name, Configuration: cfg}
+	}
+
+	pool.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(p.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cfg, err := p.poll()
+				if err != nil {
+					log.Printf("[WARN] docker provider: poll failed: %v", err)
+					continue
+				}
+				configurationChan <- provider.Message{ProviderName: Name, Configuration: cfg}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return nil
+}
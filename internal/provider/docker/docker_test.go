@@ -0,0 +1,67 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ghostkellz/ghostgate/internal/provider"
+	"github.com/ghostkellz/ghostgate/internal/safe"
+)
+
+// redirectTransport rewrites every request to target, so tests can point
+// Provider.poll's fixed "http://docker/..." URL at an httptest.Server
+// without a real Docker socket.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (rt *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestPollParsesLabels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"Labels": {"ghostgate.domain": "a.example.com", "ghostgate.backend": "http://127.0.0.1:8080"}},
+			{"Labels": {"ghostgate.domain": "b.example.com"}},
+			{"Labels": {"other": "label"}}
+		]`))
+	}))
+	defer srv.Close()
+
+	target, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parsing test server URL: %v", err)
+	}
+
+	p := New("/nonexistent/docker.sock", time.Second)
+	p.client.Transport = &redirectTransport{target: target}
+
+	cfg, err := p.poll()
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if len(cfg.Domains) != 1 || cfg.Domains[0].Domain != "a.example.com" {
+		t.Fatalf("expected exactly one fully-labeled domain, got %+v", cfg.Domains)
+	}
+}
+
+// TestProvideToleratesInitialPollFailure verifies that an unreachable
+// Docker socket at startup doesn't fail Provide (and so doesn't abort
+// Server.Run); it logs and keeps polling in the background instead.
+func TestProvideToleratesInitialPollFailure(t *testing.T) {
+	p := New("/nonexistent/docker.sock", time.Hour)
+	pool := safe.NewPool(context.Background())
+	defer pool.Stop()
+
+	ch := make(chan provider.Message, 1)
+	if err := p.Provide(ch, pool); err != nil {
+		t.Fatalf("Provide returned an error for an unreachable endpoint: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+// Package etcd is a provider that discovers domains from an etcd v3 key
+// prefix, polling etcd's JSON gRPC-gateway (no etcd client dependency).
+// Each key under Prefix holds a YAML-encoded config.DomainConfig, the
+// same convention the consul provider uses.
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ghostkellz/ghostgate/internal/config"
+	"github.com/ghostkellz/ghostgate/internal/dynamic"
+	"github.com/ghostkellz/ghostgate/internal/provider"
+	"github.com/ghostkellz/ghostgate/internal/safe"
+)
+
+// Name is the provider name used in Message.ProviderName.
+const Name = "etcd"
+
+// Provider polls an etcd cluster's v3 gRPC-gateway.
+type Provider struct {
+	// Endpoint is the etcd gRPC-gateway base URL, e.g. "http://127.0.0.1:2379".
+	Endpoint string
+	// Prefix is the key prefix to watch, e.g. "/ghostgate/domains/".
+	Prefix string
+	// PollInterval controls how often the prefix is re-read. Defaults to 5s.
+	PollInterval time.Duration
+
+	client *http.Client
+}
+
+// New builds an etcd Provider.
+func New(endpoint, prefix string, pollInterval time.Duration) *Provider {
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	return &Provider{
+		Endpoint:     strings.TrimRight(endpoint, "/"),
+		Prefix:       prefix,
+		PollInterval: pollInterval,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// rangeRequest/rangeResponse model the subset of etcd's v3 JSON gateway
+// we need: https://etcd.io/docs/latest/dev-guide/api_grpc_gateway/
+type rangeRequest struct {
+	Key      string `json:"key"`
+	RangeEnd string `json:"range_end"`
+}
+
+type rangeResponse struct {
+	Kvs []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"kvs"`
+}
+
+// prefixRangeEnd computes the range_end that makes a Range request cover
+// every key with the given prefix, per etcd's convention.
+func prefixRangeEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return base64.StdEncoding.EncodeToString(end[:i+1])
+		}
+	}
+	return "" // prefix is all 0xff bytes: unbounded range
+}
+
+func (p *Provider) poll() (*dynamic.Configuration, error) {
+	reqBody, err := json.Marshal(rangeRequest{
+		Key:      base64.StdEncoding.EncodeToString([]byte(p.Prefix)),
+		RangeEnd: prefixRangeEnd(p.Prefix),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Post(p.Endpoint+"/v3/kv/range", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("etcd provider: unexpected status %s", resp.Status)
+	}
+
+	var rr rangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rr); err != nil {
+		return nil, err
+	}
+
+	var domains []config.DomainConfig
+	for _, kv := range rr.Kvs {
+		raw, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			log.Printf("[WARN] etcd provider: key %s: invalid base64: %v", kv.Key, err)
+			continue
+		}
+		var d config.DomainConfig
+		if err := yaml.Unmarshal(raw, &d); err != nil {
+			log.Printf("[WARN] etcd provider: key %s: invalid domain config: %v", kv.Key, err)
+			continue
+		}
+		if d.Domain != "" {
+			domains = append(domains, d)
+		}
+	}
+
+	return &dynamic.Configuration{Domains: domains}, nil
+}
+
+// Provide polls the etcd prefix every PollInterval and publishes a
+// Message after every successful poll, whether or not the configuration
+// changed. A failed initial poll
+// (e.g. the etcd cluster isn't reachable yet) is logged, not returned,
+// so one unreachable provider can't take down Server.Run: discovery
+// simply starts empty and picks up keys once etcd answers.
+func (p *Provider) Provide(configurationChan chan<- provider.Message, pool *safe.Pool) error {
+	if cfg, err := p.poll(); err != nil {
+		log.Printf("[WARN] etcd provider: initial poll failed: %v", err)
+	} else {
+		configurationChan <- provider.Message{ProviderName: Name, Configuration: cfg}
+	}
+
+	pool.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(p.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cfg, err := p.poll()
+				if err != nil {
+					log.Printf("[WARN] etcd provider: poll failed: %v", err)
+					continue
+				}
+				configurationChan <- provider.Message{ProviderName: Name, Configuration: cfg}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package etcd
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ghostkellz/ghostgate/internal/provider"
+	"github.com/ghostkellz/ghostgate/internal/safe"
+)
+
+func TestPollParsesRangeResponse(t *testing.T) {
+	good := base64.StdEncoding.EncodeToString([]byte("domain: a.example.com\nproxy_routes:\n  - path: /\n    backend: http://127.0.0.1:8080\n"))
+	bad := base64.StdEncoding.EncodeToString([]byte("not: [valid"))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"kvs": [
+			{"key": "` + base64.StdEncoding.EncodeToString([]byte("/ghostgate/domains/a")) + `", "value": "` + good + `"},
+			{"key": "` + base64.StdEncoding.EncodeToString([]byte("/ghostgate/domains/b")) + `", "value": "` + bad + `"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	p := New(srv.URL, "/ghostgate/domains/", time.Second)
+	cfg, err := p.poll()
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if len(cfg.Domains) != 1 || cfg.Domains[0].Domain != "a.example.com" {
+		t.Fatalf("expected exactly one valid domain, got %+v", cfg.Domains)
+	}
+}
+
+func TestPrefixRangeEnd(t *testing.T) {
+	end := prefixRangeEnd("/ghostgate/")
+	decoded, err := base64.StdEncoding.DecodeString(end)
+	if err != nil {
+		t.Fatalf("decoding range_end: %v", err)
+	}
+	if string(decoded) != "/ghostgate0" {
+		t.Fatalf("expected the prefix's last byte incremented, got %q", decoded)
+	}
+}
+
+// TestProvideToleratesInitialPollFailure verifies that an unreachable
+// etcd cluster at startup doesn't fail Provide (and so doesn't abort
+// Server.Run); it logs and keeps polling in the background instead.
+func TestProvideToleratesInitialPollFailure(t *testing.T) {
+	p := New("http://127.0.0.1:1", "/ghostgate/domains/", time.Hour)
+	pool := safe.NewPool(context.Background())
+	defer pool.Stop()
+
+	ch := make(chan provider.Message, 1)
+	if err := p.Provide(ch, pool); err != nil {
+		t.Fatalf("Provide returned an error for an unreachable endpoint: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+// Package file is the original, and default, GhostGate provider: it
+// loads domains from a main YAML file plus a conf.d directory of
+// additional *.conf files, optionally re-publishing whenever any of them
+// change on disk.
+package file
+
+import (
+	"context"
+	"log"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/ghostkellz/ghostgate/internal/config"
+	"github.com/ghostkellz/ghostgate/internal/dynamic"
+	"github.com/ghostkellz/ghostgate/internal/provider"
+	"github.com/ghostkellz/ghostgate/internal/safe"
+)
+
+// Name is the provider name used in Message.ProviderName.
+const Name = "file"
+
+// Provider loads domains from ConfigPath and ConfDir.
+type Provider struct {
+	ConfigPath string
+	ConfDir    string
+	Watch      bool
+}
+
+// New builds a file Provider.
+func New(configPath, confDir string, watch bool) *Provider {
+	return &Provider{ConfigPath: configPath, ConfDir: confDir, Watch: watch}
+}
+
+// Provide loads the configured files and sends an initial Message, then,
+// if Watch is set, keeps sending updated Messages as the files change.
+func (p *Provider) Provide(configurationChan chan<- provider.Message, pool *safe.Pool) error {
+	load := func() {
+		cfg, err := config.LoadWithConfDir(p.ConfigPath, p.ConfDir)
+		if err != nil {
+			log.Printf("[WARN] file provider: failed to load %s: %v", p.ConfigPath, err)
+			return
+		}
+		configurationChan <- provider.Message{
+			ProviderName:  Name,
+			Configuration: &dynamic.Configuration{Domains: cfg.Domains},
+		}
+	}
+
+	load()
+
+	if !p.Watch {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(p.ConfigPath); err != nil {
+		watcher.Close()
+		return err
+	}
+	if err := watcher.Add(p.ConfDir); err != nil {
+		log.Printf("[WARN] file provider: could not watch %s: %v", p.ConfDir, err)
+	}
+
+	pool.Go(func(ctx context.Context) {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+					load()
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("[WARN] file provider: watcher error: %v", err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return nil
+}
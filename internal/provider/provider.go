@@ -0,0 +1,26 @@
+// Package provider defines the interface dynamic configuration sources
+// implement, modeled after Traefik's provider abstraction: each provider
+// watches its own source (a YAML file, the Docker API, a Consul/etcd KV
+// prefix) and publishes Messages whenever it changes.
+package provider
+
+import (
+	"github.com/ghostkellz/ghostgate/internal/dynamic"
+	"github.com/ghostkellz/ghostgate/internal/safe"
+)
+
+// Message is one provider's view of its slice of the routing table.
+type Message struct {
+	ProviderName  string
+	Configuration *dynamic.Configuration
+}
+
+// Provider watches a configuration source and publishes a Message to
+// configurationChan every time its view of the world changes. Provide
+// should use pool to run any background watch/poll loops so they are
+// cancelled when the pool is stopped, and should return once its initial
+// watch is established (it does not block for the lifetime of the
+// watch).
+type Provider interface {
+	Provide(configurationChan chan<- Message, pool *safe.Pool) error
+}
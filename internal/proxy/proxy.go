@@ -0,0 +1,99 @@
+// Package proxy builds the reverse-proxy handlers GhostGate mounts for
+// each configured route.
+package proxy
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/ghostkellz/ghostgate/internal/clientip"
+	"github.com/ghostkellz/ghostgate/internal/config"
+	"github.com/ghostkellz/ghostgate/internal/metrics"
+	"github.com/ghostkellz/ghostgate/internal/ratelimit"
+)
+
+// New builds a reverse proxy for route.Backend. Errors reaching the
+// upstream (dial failures, timeouts) are counted in
+// ghostgate_upstream_errors_total before falling back to the default
+// 502 response.
+func New(domain string, route config.ProxyRoute) (*httputil.ReverseProxy, error) {
+	backendURL, err := url.Parse(route.Backend)
+	if err != nil {
+		return nil, err
+	}
+	rp := httputil.NewSingleHostReverseProxy(backendURL)
+	rp.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		metrics.UpstreamErrors.WithLabelValues(domain, route.Path).Inc()
+		log.Printf("[WARN] upstream error for domain=%s path=%s: %v", domain, route.Path, err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return rp, nil
+}
+
+// RouteHandler wraps proxy with route's per-client rate limit and static
+// header injection. limiter is nil when the effective rate limit (route,
+// falling back to domain) is zero; resolver recovers the real client IP
+// behind any trusted proxy to key the limiter and is always required
+// since Security already builds one per domain.
+func RouteHandler(domain string, route config.ProxyRoute, proxy *httputil.ReverseProxy, limiter ratelimit.Limiter, resolver *clientip.Resolver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if limiter != nil {
+			ip := resolver.Resolve(r)
+			result := limiter.Allow(ip)
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			if !result.Allowed {
+				metrics.RateLimitRejections.WithLabelValues(domain, route.Path).Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+				http.Error(w, "429 - Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+		for k, v := range route.Headers {
+			r.Header.Set(k, v)
+		}
+		proxy.ServeHTTP(w, r)
+	}
+}
+
+// NewLimiter builds the Limiter RouteHandler should use for route, given
+// domain as domain-wide defaults and redisAddr (if non-empty) to share
+// buckets across instances. It returns nil if no rate limit applies.
+func NewLimiter(redisAddr, keyPrefix string, domain config.DomainConfig, route config.ProxyRoute) ratelimit.Limiter {
+	limit := route.RateLimit
+	if limit == 0 {
+		limit = domain.RateLimit
+	}
+	if limit <= 0 {
+		return nil
+	}
+	burst := route.RateLimitBurst
+	if burst == 0 {
+		burst = domain.RateLimitBurst
+	}
+	if burst <= 0 {
+		burst = 5
+	}
+	if redisAddr != "" {
+		return ratelimit.NewRedis(redisAddr, keyPrefix, limit, burst)
+	}
+	return ratelimit.NewLocal(limit, burst)
+}
+
+// RegexHandler wraps handler so it only fires when r.URL.Path matches
+// route.Path as a regular expression.
+func RegexHandler(route config.ProxyRoute, handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		matched, _ := regexp.MatchString(route.Path, r.URL.Path)
+		if matched {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+}
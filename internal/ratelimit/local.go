@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"hash/fnv"
+	"net/netip"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	numShards  = 32
+	idleTTL    = 10 * time.Minute
+	gcInterval = 5 * time.Minute
+)
+
+// Local is a per-IP token-bucket Limiter, sharded across striped mutexes
+// so concurrent clients don't contend on a single lock. Idle entries are
+// swept opportunistically (no background goroutine, so it's safe to
+// create and discard a Local on every config reload).
+type Local struct {
+	limit  int
+	rate   rate.Limit
+	burst  int
+	shards [numShards]*shard
+}
+
+type shard struct {
+	mu        sync.Mutex
+	entries   map[netip.Addr]*bucketEntry
+	lastSwept time.Time
+}
+
+type bucketEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewLocal builds a Local allowing up to limit requests/second per client
+// IP, with bursts up to burst.
+func NewLocal(limit, burst int) *Local {
+	l := &Local{limit: limit, rate: rate.Limit(limit), burst: burst}
+	for i := range l.shards {
+		l.shards[i] = &shard{entries: make(map[netip.Addr]*bucketEntry)}
+	}
+	return l
+}
+
+func (l *Local) shardFor(ip netip.Addr) *shard {
+	h := fnv.New32a()
+	b := ip.As16()
+	h.Write(b[:])
+	return l.shards[h.Sum32()%numShards]
+}
+
+func (l *Local) Allow(ip netip.Addr) Result {
+	s := l.shardFor(ip)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSwept) > gcInterval {
+		for addr, e := range s.entries {
+			if now.Sub(e.lastSeen) > idleTTL {
+				delete(s.entries, addr)
+			}
+		}
+		s.lastSwept = now
+	}
+
+	e, ok := s.entries[ip]
+	if !ok {
+		e = &bucketEntry{limiter: rate.NewLimiter(l.rate, l.burst)}
+		s.entries[ip] = e
+	}
+	e.lastSeen = now
+
+	res := e.limiter.ReserveN(now, 1)
+	if !res.OK() {
+		return Result{Allowed: false, Limit: l.limit, Remaining: 0, RetryAfter: time.Second}
+	}
+	delay := res.DelayFrom(now)
+	if delay > 0 {
+		res.CancelAt(now)
+		return Result{Allowed: false, Limit: l.limit, Remaining: 0, RetryAfter: delay}
+	}
+	return Result{Allowed: true, Limit: l.limit, Remaining: int(e.limiter.TokensAt(now))}
+}
@@ -0,0 +1,24 @@
+// Package ratelimit enforces per-client request limits. Limiter is
+// implemented either in-memory, with a sharded map[netip.Addr]*rate.Limiter
+// (Local), or against a shared Redis bucket (Redis) so multiple GhostGate
+// instances agree on one limit.
+package ratelimit
+
+import (
+	"net/netip"
+	"time"
+)
+
+// Result is the outcome of a single Allow check, carrying enough detail
+// to populate Retry-After and X-RateLimit-* response headers.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter decides whether ip may make another request right now.
+type Limiter interface {
+	Allow(ip netip.Addr) Result
+}
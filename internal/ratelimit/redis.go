@@ -0,0 +1,226 @@
+package ratelimit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+// redisPoolSize caps how many idle connections Redis keeps ready to
+// reuse. Concurrent Allow calls beyond this just dial an extra
+// connection each, used once and then discarded instead of pooled.
+const redisPoolSize = 8
+
+// gcraScript implements the Generic Cell Rate Algorithm against a single
+// key holding the "theoretical arrival time" (TAT) of the next allowed
+// request, in milliseconds. KEYS[1] is the bucket key; ARGV is
+// (emission_interval_ms, delay_variation_tolerance_ms, now_ms).
+//
+// It returns {allowed (0/1), retry_after_ms, remaining}.
+const gcraScript = `
+local key = KEYS[1]
+local emission_interval = tonumber(ARGV[1])
+local dvt = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local tat = tonumber(redis.call("GET", key))
+if tat == nil or tat < now then
+	tat = now
+end
+
+local new_tat = tat + emission_interval
+local allow_at = new_tat - dvt
+
+if allow_at > now then
+	local retry_after = allow_at - now
+	return {0, retry_after, 0}
+end
+
+redis.call("SET", key, new_tat, "PX", math.floor(dvt + emission_interval))
+local remaining = math.floor((now - allow_at) / emission_interval)
+return {1, 0, remaining}
+`
+
+// Redis is a Limiter backed by a shared Redis GCRA bucket per key, so
+// multiple GhostGate instances enforce one limit together. It speaks just
+// enough RESP over a pool of net.Conns to run EVAL; no redis client
+// dependency is pulled in, matching how GhostGate's other optional
+// integrations (Docker/Consul/etcd providers) talk to their backend
+// directly over the wire. Connections are pooled rather than shared
+// behind one mutex, so concurrent Allow calls on the same limiter pipeline
+// across the pool instead of serializing on a single round-trip.
+type Redis struct {
+	addr      string
+	keyPrefix string
+	limit     int
+	burst     int
+	interval  time.Duration // emission interval: time between 1 token at `limit`
+
+	pool chan net.Conn
+}
+
+// NewRedis builds a Redis-backed Limiter sharing keyPrefix-scoped buckets
+// at addr (host:port), allowing up to limit requests/second per client IP
+// with bursts up to burst.
+func NewRedis(addr, keyPrefix string, limit, burst int) *Redis {
+	return &Redis{
+		addr:      addr,
+		keyPrefix: keyPrefix,
+		limit:     limit,
+		burst:     burst,
+		interval:  time.Second / time.Duration(limit),
+		pool:      make(chan net.Conn, redisPoolSize),
+	}
+}
+
+// getConn returns an idle pooled connection, or dials a new one if none
+// is free.
+func (r *Redis) getConn() (net.Conn, error) {
+	select {
+	case conn := <-r.pool:
+		return conn, nil
+	default:
+		return net.DialTimeout("tcp", r.addr, 2*time.Second)
+	}
+}
+
+// putConn returns conn to the pool for reuse, closing it instead if the
+// pool is already full.
+func (r *Redis) putConn(conn net.Conn) {
+	select {
+	case r.pool <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+func (r *Redis) Allow(ip netip.Addr) Result {
+	key := r.keyPrefix + "|" + ip.String()
+	now := time.Now()
+	emissionMS := r.interval.Milliseconds()
+	dvtMS := emissionMS * int64(r.burst)
+	nowMS := now.UnixMilli()
+
+	reply, err := r.eval(gcraScript, key, emissionMS, dvtMS, nowMS)
+	if err != nil {
+		// Fail open: a Redis outage shouldn't take the proxy down with it.
+		return Result{Allowed: true, Limit: r.limit, Remaining: r.burst}
+	}
+
+	allowed, _ := reply[0].(int64)
+	retryAfterMS, _ := reply[1].(int64)
+	remaining, _ := reply[2].(int64)
+	return Result{
+		Allowed:    allowed == 1,
+		Limit:      r.limit,
+		Remaining:  int(remaining),
+		RetryAfter: time.Duration(retryAfterMS) * time.Millisecond,
+	}
+}
+
+func (r *Redis) eval(script, key string, args ...int64) ([]any, error) {
+	conn, err := r.getConn()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := make([]string, 0, 4+len(args))
+	cmd = append(cmd, "EVAL", script, "1", key)
+	for _, a := range args {
+		cmd = append(cmd, strconv.FormatInt(a, 10))
+	}
+
+	if err := writeRESPCommand(conn, cmd); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reply, err := readRESPReply(bufio.NewReader(conn))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	arr, ok := reply.([]any)
+	if !ok || len(arr) != 3 {
+		// The connection may be left mid-protocol; don't return it to the
+		// pool for a future caller to desync on.
+		conn.Close()
+		return nil, fmt.Errorf("ratelimit: unexpected GCRA script reply %#v", reply)
+	}
+	r.putConn(conn)
+	return arr, nil
+}
+
+// writeRESPCommand writes args as a RESP array of bulk strings.
+func writeRESPCommand(w net.Conn, args []string) error {
+	buf := fmt.Sprintf("*%d\r\n", len(args))
+	for _, a := range args {
+		buf += fmt.Sprintf("$%d\r\n%s\r\n", len(a), a)
+	}
+	_, err := w.Write([]byte(buf))
+	return err
+}
+
+// readRESPReply parses a single RESP2 reply: simple string (+), error
+// (-), integer (:), bulk string ($), or array (*) of any of those.
+func readRESPReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("ratelimit: empty RESP reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("ratelimit: redis error: %s", line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		return n, err
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2) // value + trailing CRLF
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		arr := make([]any, n)
+		for i := range arr {
+			v, err := readRESPReply(r)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = v
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("ratelimit: unrecognized RESP reply type %q", line[0])
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return line[:len(line)-2], nil // trim trailing \r\n
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"bufio"
+	"net"
+	"testing"
+)
+
+func TestWriteAndReadRESPCommand(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- writeRESPCommand(client, []string{"EVAL", "return 1", "1", "mykey"})
+	}()
+
+	reader := bufio.NewReader(server)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading array header: %v", err)
+	}
+	if line != "*4\r\n" {
+		t.Fatalf("array header = %q, want %q", line, "*4\r\n")
+	}
+
+	wantBulk := []string{"EVAL", "return 1", "1", "mykey"}
+	for _, want := range wantBulk {
+		lenLine, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading bulk length: %v", err)
+		}
+		if lenLine[0] != '$' {
+			t.Fatalf("bulk length line = %q, want $-prefixed", lenLine)
+		}
+		valLine, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading bulk value: %v", err)
+		}
+		if valLine[:len(valLine)-2] != want {
+			t.Fatalf("bulk value = %q, want %q", valLine[:len(valLine)-2], want)
+		}
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("writeRESPCommand: %v", err)
+	}
+}
+
+func TestReadRESPReplyArrayOfMixedTypes(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	go func() {
+		client.Write([]byte("*3\r\n:1\r\n:42\r\n$5\r\nhello\r\n"))
+	}()
+
+	reply, err := readRESPReply(bufio.NewReader(server))
+	if err != nil {
+		t.Fatalf("readRESPReply: %v", err)
+	}
+	arr, ok := reply.([]any)
+	if !ok || len(arr) != 3 {
+		t.Fatalf("reply = %#v, want a 3-element array", reply)
+	}
+	if arr[0] != int64(1) || arr[1] != int64(42) {
+		t.Fatalf("reply integers = %v, %v, want 1, 42", arr[0], arr[1])
+	}
+	if arr[2] != "hello" {
+		t.Fatalf("reply bulk string = %q, want %q", arr[2], "hello")
+	}
+}
+
+func TestReadRESPReplyError(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	go func() {
+		client.Write([]byte("-ERR something went wrong\r\n"))
+	}()
+
+	if _, err := readRESPReply(bufio.NewReader(server)); err == nil {
+		t.Fatal("expected an error for a RESP error reply")
+	}
+}
+
+func TestRedisConnPoolReusesConnections(t *testing.T) {
+	r := &Redis{pool: make(chan net.Conn, redisPoolSize)}
+
+	a, _ := net.Pipe()
+	r.putConn(a)
+
+	got, err := r.getConn()
+	if err != nil {
+		t.Fatalf("getConn: %v", err)
+	}
+	if got != a {
+		t.Fatal("getConn did not return the pooled connection")
+	}
+	a.Close()
+}
+
+func TestRedisConnPoolDropsConnectionsPastCapacity(t *testing.T) {
+	r := &Redis{pool: make(chan net.Conn, 1)}
+
+	a, aPeer := net.Pipe()
+	b, bPeer := net.Pipe()
+	defer aPeer.Close()
+	defer bPeer.Close()
+
+	r.putConn(a)
+	r.putConn(b) // pool is full; b must be closed rather than blocking
+
+	if _, err := b.Write([]byte("x")); err == nil {
+		t.Fatal("expected writing to the dropped connection to fail")
+	}
+
+	got, err := r.getConn()
+	if err != nil {
+		t.Fatalf("getConn: %v", err)
+	}
+	if got != a {
+		t.Fatal("getConn did not return the connection kept in the pool")
+	}
+	a.Close()
+}
@@ -0,0 +1,46 @@
+// Package safe provides a small goroutine pool that ties every goroutine
+// it launches to a shared context, so a provider (or anything else
+// spawning background work) can be stopped cleanly. Modeled after
+// Traefik's safe.Pool.
+package safe
+
+import (
+	"context"
+	"sync"
+)
+
+// Pool tracks goroutines started with Go and cancels their context when
+// Stop is called, waiting for all of them to return.
+type Pool struct {
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewPool builds a Pool whose goroutines are cancelled when either ctx is
+// cancelled or Stop is called.
+func NewPool(ctx context.Context) *Pool {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pool{ctx: ctx, cancel: cancel}
+}
+
+// Go runs fn in a new goroutine, passing it the pool's context.
+func (p *Pool) Go(fn func(ctx context.Context)) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		fn(p.ctx)
+	}()
+}
+
+// Ctx returns the context goroutines started with Go are run with.
+func (p *Pool) Ctx() context.Context {
+	return p.ctx
+}
+
+// Stop cancels the pool's context and waits for every goroutine started
+// with Go to return.
+func (p *Pool) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
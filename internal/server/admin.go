@@ -0,0 +1,326 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ghostkellz/ghostgate/internal/certs"
+	"github.com/ghostkellz/ghostgate/internal/config"
+)
+
+// newAdminAPI builds GhostGate's admin control plane: a versioned REST
+// API under /api/v1 for inspecting and live-editing configuration, the
+// legacy /admin/reload trigger, and an embedded HTML dashboard at "/".
+// Every route is protected by auth (bearer token and/or the mTLS client
+// certificate the caller configures on the admin listener) and served
+// with permissive CORS headers so the dashboard can be hosted separately
+// from the API origin.
+func newAdminAPI(s *Server, auth config.AdminAPIAuthConfig) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/admin/reload", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.reloadFromDisk()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Reloaded"))
+	})
+
+	mux.HandleFunc("/api/v1/health", s.handleAdminHealth)
+	mux.HandleFunc("/api/v1/config", s.handleAdminConfig)
+	mux.HandleFunc("/api/v1/domains", s.handleAdminDomains)
+	mux.HandleFunc("/api/v1/domains/", s.handleAdminDomainByName)
+	mux.HandleFunc("/api/v1/certs", s.handleAdminCerts)
+	mux.HandleFunc("/api/v1/certs/", s.handleAdminCertRenew)
+	mux.HandleFunc("/api/v1/routes", s.handleAdminRoutes)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := adminDashboardFS.ReadFile("adminui/dashboard.html")
+		if err != nil {
+			http.Error(w, "dashboard unavailable", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(data)
+	})
+
+	return corsMiddleware(adminAuthMiddleware(auth, mux))
+}
+
+// adminAuthMiddleware enforces auth.Token as a bearer token. mTLS client
+// certificate verification (auth.ClientCA) happens at the TLS handshake
+// layer via tls.RequireAndVerifyClientCert, so there's nothing left to
+// check here for that case.
+func adminAuthMiddleware(auth config.AdminAPIAuthConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.Token != "" {
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if got == "" || !constantTimeEqual(got, auth.Token) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// constantTimeEqual compares two bearer tokens without leaking their
+// length or content through timing, hashing both to a fixed size first
+// since subtle.ConstantTimeCompare isn't constant-time across inputs of
+// different lengths.
+func constantTimeEqual(got, want string) bool {
+	gotSum := sha256.Sum256([]byte(got))
+	wantSum := sha256.Sum256([]byte(want))
+	return subtle.ConstantTimeCompare(gotSum[:], wantSum[:]) == 1
+}
+
+// corsMiddleware allows the admin dashboard (or any other browser-based
+// client) to call the API cross-origin.
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// loadClientCAs reads a PEM file of CA certificates for verifying admin
+// API client certificates.
+func loadClientCAs(path string) (*x509.CertPool, error) {
+	pemData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// adminHealth is the GET /api/v1/health response: a snapshot of the
+// subsystems the admin API can see into.
+type adminHealth struct {
+	Status           string `json:"status"`
+	UptimeSeconds    int64  `json:"uptime_seconds"`
+	Domains          int    `json:"domains"`
+	CertMode         string `json:"cert_mode"`
+	CertsLoaded      int    `json:"certs_loaded"`
+	RateLimitBackend string `json:"rate_limit_backend"`
+	DynamicProviders int    `json:"dynamic_providers"`
+}
+
+func (s *Server) handleAdminHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfg := s.Config()
+
+	certMode, certsLoaded := "store", 0
+	for _, d := range cfg.Domains {
+		if d.Autocert {
+			certMode = "acme"
+			break
+		}
+	}
+	if certList := s.CertStatuses(); certList != nil {
+		certsLoaded = len(certList)
+	}
+
+	rateLimitBackend := "local"
+	if cfg.RateLimit.RedisAddr != "" {
+		rateLimitBackend = "redis"
+	}
+
+	s.mu.RLock()
+	providers := len(s.providers)
+	s.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, adminHealth{
+		Status:           "ok",
+		UptimeSeconds:    int64(time.Since(s.startTime).Seconds()),
+		Domains:          len(cfg.Domains),
+		CertMode:         certMode,
+		CertsLoaded:      certsLoaded,
+		RateLimitBackend: rateLimitBackend,
+		DynamicProviders: providers,
+	})
+}
+
+// handleAdminConfig serves GET /api/v1/config (the running configuration,
+// with the admin API token redacted) and PUT /api/v1/config (parse,
+// validate, then atomically swap in a new YAML configuration document).
+func (s *Server) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		cfg := *s.Config()
+		if cfg.Server.AdminAPIAuth.Token != "" {
+			cfg.Server.AdminAPIAuth.Token = "REDACTED"
+		}
+		cfg.Domains = append([]config.DomainConfig(nil), cfg.Domains...)
+		for i, d := range cfg.Domains {
+			if len(d.ACME.DNSProviderConfig) > 0 {
+				redacted := make(map[string]string, len(d.ACME.DNSProviderConfig))
+				for k := range d.ACME.DNSProviderConfig {
+					redacted[k] = "REDACTED"
+				}
+				d.ACME.DNSProviderConfig = redacted
+			}
+			if d.ACME.EABHMACKey != "" {
+				d.ACME.EABHMACKey = "REDACTED"
+			}
+			cfg.Domains[i] = d
+		}
+		writeJSON(w, http.StatusOK, cfg)
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		newCfg, err := config.Parse(body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid configuration: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := config.Validate(newCfg); err != nil {
+			http.Error(w, fmt.Sprintf("invalid configuration: %v", err), http.StatusBadRequest)
+			return
+		}
+		s.Reload(newCfg)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminDomains serves GET /api/v1/domains (list) and POST
+// /api/v1/domains (add one, as a JSON-encoded config.DomainConfig).
+func (s *Server) handleAdminDomains(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.Config().Domains)
+	case http.MethodPost:
+		var d config.DomainConfig
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			http.Error(w, fmt.Sprintf("invalid domain: %v", err), http.StatusBadRequest)
+			return
+		}
+		if d.Domain == "" {
+			http.Error(w, "domain is required", http.StatusBadRequest)
+			return
+		}
+		if err := s.AddDomain(d); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminDomainByName serves DELETE /api/v1/domains/{name}.
+func (s *Server) handleAdminDomainByName(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/v1/domains/")
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.RemoveDomain(name); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminCerts serves GET /api/v1/certs.
+func (s *Server) handleAdminCerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	certList := s.CertStatuses()
+	if certList == nil {
+		certList = []certs.CertInfo{}
+	}
+	writeJSON(w, http.StatusOK, certList)
+}
+
+// handleAdminCertRenew serves POST /api/v1/certs/{domain}/renew.
+func (s *Server) handleAdminCertRenew(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/v1/certs/")
+	domain, action, ok := strings.Cut(rest, "/")
+	if !ok || action != "renew" || domain == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.RenewCert(domain); err != nil {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// adminRoute is one flattened entry in the GET /api/v1/routes response.
+type adminRoute struct {
+	Domain  string `json:"domain"`
+	Path    string `json:"path"`
+	Backend string `json:"backend"`
+	Regex   bool   `json:"regex"`
+	Cache   bool   `json:"cache"`
+}
+
+func (s *Server) handleAdminRoutes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	routes := []adminRoute{}
+	for _, d := range s.Config().Domains {
+		for _, route := range d.ProxyRoutes {
+			routes = append(routes, adminRoute{
+				Domain:  d.Domain,
+				Path:    route.Path,
+				Backend: route.Backend,
+				Regex:   route.Regex,
+				Cache:   route.Cache,
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, routes)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,9 @@
+package server
+
+import "embed"
+
+// adminDashboardFS embeds the static HTML dashboard served at the admin
+// API's "/".
+//
+//go:embed adminui/dashboard.html
+var adminDashboardFS embed.FS
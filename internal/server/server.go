@@ -0,0 +1,482 @@
+// Package server wires GhostGate's config, certs, middleware, and proxy
+// packages into a runnable HTTP(S) gateway, so it can be run standalone
+// or embedded as a library.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/ghostkellz/ghostgate/internal/acme"
+	"github.com/ghostkellz/ghostgate/internal/certs"
+	"github.com/ghostkellz/ghostgate/internal/clientip"
+	"github.com/ghostkellz/ghostgate/internal/config"
+	"github.com/ghostkellz/ghostgate/internal/domain"
+	"github.com/ghostkellz/ghostgate/internal/dynamic"
+	"github.com/ghostkellz/ghostgate/internal/httpcache"
+	"github.com/ghostkellz/ghostgate/internal/logging"
+	"github.com/ghostkellz/ghostgate/internal/middleware"
+	"github.com/ghostkellz/ghostgate/internal/provider"
+	"github.com/ghostkellz/ghostgate/internal/provider/file"
+	"github.com/ghostkellz/ghostgate/internal/proxy"
+	"github.com/ghostkellz/ghostgate/internal/safe"
+	"github.com/ghostkellz/ghostgate/internal/watcher"
+)
+
+// Server is a running (or runnable) GhostGate gateway. Build one with New
+// and start it with Run.
+type Server struct {
+	mu  sync.RWMutex
+	cfg *config.Config
+
+	configPath string
+	confDir    string
+
+	providers []provider.Provider
+
+	cache *httpcache.Cache
+
+	accessLoggers []*logging.AccessLogger
+	certStore     *certs.Store
+	acmeManager   *acme.Manager
+	startTime     time.Time
+
+	httpServer  *http.Server
+	httpsServer *http.Server
+	adminServer *http.Server
+}
+
+// New builds a Server from cfg. It does not open any listeners; call Run
+// to start serving.
+func New(cfg *config.Config) (*Server, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config must not be nil")
+	}
+
+	s := &Server{cfg: cfg, cache: httpcache.New(0), startTime: time.Now()}
+	s.rebuild(cfg)
+	return s, nil
+}
+
+// Config returns the configuration the server is currently serving.
+func (s *Server) Config() *config.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// CertStatuses returns the admin API's view of every certificate loaded
+// from server.certs_dir, or nil before the server's TLS listener has
+// been configured.
+func (s *Server) CertStatuses() []certs.CertInfo {
+	s.mu.RLock()
+	store := s.certStore
+	s.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+	return store.Status()
+}
+
+// RenewCert renews domain's certificate now, for the admin API's manual
+// "renew" endpoint. Autocert domains are renewed via GhostGate's ACME
+// manager regardless of remaining validity; all others are handled by
+// re-scanning the store's directory for whatever an operator or external
+// issuer has already written there.
+func (s *Server) RenewCert(domain string) error {
+	s.mu.RLock()
+	store := s.certStore
+	mgr := s.acmeManager
+	cfg := s.cfg
+	s.mu.RUnlock()
+	if store == nil {
+		return fmt.Errorf("certificate renewal requires server.certs_dir")
+	}
+
+	for _, d := range cfg.Domains {
+		if d.Domain == domain && d.Autocert {
+			if mgr == nil {
+				return fmt.Errorf("acme manager is not initialized")
+			}
+			return mgr.EnsureCert(context.Background(), d, true)
+		}
+	}
+	return store.Rescan()
+}
+
+// AddDomain appends d to the running configuration and rebuilds the
+// server around it. It errors if a domain with the same name already
+// exists.
+func (s *Server) AddDomain(d config.DomainConfig) error {
+	cfg := s.Config()
+	for _, existing := range cfg.Domains {
+		if existing.Domain == d.Domain {
+			return fmt.Errorf("domain %q already exists", d.Domain)
+		}
+	}
+	newCfg := *cfg
+	newCfg.Domains = append(append([]config.DomainConfig{}, cfg.Domains...), d)
+	s.Reload(&newCfg)
+	return nil
+}
+
+// RemoveDomain drops the named domain from the running configuration and
+// rebuilds the server. It errors if no such domain is configured.
+func (s *Server) RemoveDomain(name string) error {
+	cfg := s.Config()
+	domains := make([]config.DomainConfig, 0, len(cfg.Domains))
+	found := false
+	for _, d := range cfg.Domains {
+		if d.Domain == name {
+			found = true
+			continue
+		}
+		domains = append(domains, d)
+	}
+	if !found {
+		return fmt.Errorf("domain %q not found", name)
+	}
+	newCfg := *cfg
+	newCfg.Domains = domains
+	s.Reload(&newCfg)
+	return nil
+}
+
+// Reload atomically swaps in a newly loaded configuration.
+func (s *Server) Reload(cfg *config.Config) {
+	s.rebuild(cfg)
+}
+
+// SetConfigSource records where cfg was loaded from, so SIGHUP/admin/
+// file-watch triggered reloads know what to re-read. Call it before Run
+// when the server was built from on-disk configuration.
+func (s *Server) SetConfigSource(configPath, confDir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configPath = configPath
+	s.confDir = confDir
+}
+
+// UseProviders switches domain discovery from the static config.Domains
+// list to the given dynamic providers, merged by a watcher.Watcher. Call
+// it before Run. The file configuration (recorded via SetConfigSource)
+// is always included as the first provider, so existing YAML-based
+// domains keep working alongside e.g. Docker or Consul discovery.
+func (s *Server) UseProviders(providers ...provider.Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.providers = providers
+}
+
+// reloadFromDisk re-reads the configuration from the path recorded via
+// SetConfigSource and swaps it in. It is a no-op if no source was set, or
+// if dynamic providers have taken over domain discovery (see
+// UseProviders), since in that case the file provider already watches
+// the same path and reloadFromDisk would otherwise clobber domains
+// contributed by the other providers.
+func (s *Server) reloadFromDisk() {
+	s.mu.RLock()
+	path, dir := s.configPath, s.confDir
+	hasProviders := len(s.providers) > 0
+	s.mu.RUnlock()
+	if path == "" || hasProviders {
+		return
+	}
+	log.Println("Reloading configurations...")
+	newCfg, err := config.LoadWithConfDir(path, dir)
+	if err != nil {
+		log.Printf("Failed to reload configurations: %v", err)
+		return
+	}
+	if err := config.Validate(newCfg); err != nil {
+		log.Printf("Reloaded configuration is invalid, keeping the current configuration: %v", err)
+		return
+	}
+	s.Reload(newCfg)
+	log.Println("Configurations reloaded successfully.")
+}
+
+func (s *Server) rebuild(cfg *config.Config) {
+	mux, accessLoggers := s.buildMux(cfg)
+	httpMux := s.buildHTTPRedirectMux(cfg)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	oldAccessLoggers := s.accessLoggers
+	s.cfg = cfg
+	s.accessLoggers = accessLoggers
+	if s.httpsServer != nil {
+		s.httpsServer.Handler = mux
+	}
+	if s.httpServer != nil {
+		s.httpServer.Handler = httpMux
+	}
+	for _, al := range oldAccessLoggers {
+		al.Close()
+	}
+}
+
+// buildMux constructs the HTTPS virtual-host mux from cfg, along with
+// every access logger it created so the caller can close the previous
+// generation's once the new mux takes over. Static file serving relies on
+// http.ServeFile's own conditional-GET handling, so only proxy routes
+// that opt in via ProxyRoute.Cache get wrapped in s.cache.
+func (s *Server) buildMux(cfg *config.Config) (*http.ServeMux, []*logging.AccessLogger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	var accessLoggers []*logging.AccessLogger
+	for _, d := range cfg.Domains {
+		accessLogger, err := logging.NewAccessLogger(d.AccessLog)
+		if err != nil {
+			log.Printf("domain %s: invalid access_log config, logging to stdout instead: %v", d.Domain, err)
+			accessLogger, _ = logging.NewAccessLogger(config.AccessLogConfig{})
+		}
+		accessLoggers = append(accessLoggers, accessLogger)
+
+		if d.StaticDir != "" {
+			h := middleware.CompressMiddleware(serveStaticFilesWithCache(d.StaticDir))
+			h = middleware.Logging(d.Domain, "/", "static:"+d.StaticDir, accessLogger, h)
+			h = middleware.RequestID(h)
+			h = middleware.Security(d, h)
+			mux.Handle("/", domain.HostHandler(d, h))
+		}
+		resolver := clientip.NewResolver(d.Domain, d.TrustedProxies, d.RealIPHeader)
+		for _, route := range d.ProxyRoutes {
+			rp, err := proxy.New(d.Domain, route)
+			if err != nil {
+				log.Printf("Invalid backend URL for domain %s path %s: %v", d.Domain, route.Path, err)
+				continue
+			}
+			limiter := proxy.NewLimiter(cfg.RateLimit.RedisAddr, d.Domain+"|"+route.Path, d, route)
+			var handler http.Handler
+			if route.Regex {
+				handler = proxy.RegexHandler(route, proxy.RouteHandler(d.Domain, route, rp, limiter, resolver))
+			} else {
+				handler = proxy.RouteHandler(d.Domain, route, rp, limiter, resolver)
+			}
+			h := handler
+			if route.Cache {
+				h = s.cache.Middleware(d.Domain, h)
+			}
+			h = middleware.Logging(d.Domain, route.Path, route.Backend, accessLogger, h)
+			h = middleware.RequestID(h)
+			h = middleware.Security(d, h)
+			mux.Handle(route.Path, domain.HostHandler(d, h))
+		}
+	}
+
+	if len(cfg.Domains) == 0 {
+		mux.Handle("/", serveWelcomePage())
+		log.Println("Serving default Welcome to GhostGate page")
+	} else {
+		mux.Handle("/health", serveHealthCheck())
+	}
+	return mux, accessLoggers
+}
+
+// buildHTTPRedirectMux constructs the plain-HTTP mux used to redirect to
+// HTTPS (or serve the welcome page when no domains are configured). It
+// also answers ACME HTTP-01 challenges when the server's certificates
+// are (at least partly) managed by acme.Manager.
+func (s *Server) buildHTTPRedirectMux(cfg *config.Config) *http.ServeMux {
+	httpMux := http.NewServeMux()
+	for _, d := range cfg.Domains {
+		if d.RedirectToHTTPS {
+			httpMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "https://"+r.Host+r.URL.String(), http.StatusMovedPermanently)
+			})
+		}
+	}
+	if len(cfg.Domains) == 0 {
+		httpMux.Handle("/", serveWelcomePage())
+	}
+	s.mu.RLock()
+	mgr := s.acmeManager
+	s.mu.RUnlock()
+	if mgr != nil {
+		httpMux.Handle("/.well-known/acme-challenge/", mgr.HTTPHandler())
+	}
+	return httpMux
+}
+
+// tlsConfig builds the TLS configuration GhostGate serves HTTPS with. It
+// requires server.certs_dir: that directory backs the SNI certificate
+// store every TLS-serving domain resolves against, whether its
+// certificates were dropped in by an operator, minted by the dev CA
+// (dev_mode), or issued by acmeManager.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	cfg := s.Config()
+	if cfg.Server.CertsDir == "" {
+		for _, d := range cfg.Domains {
+			if d.Autocert {
+				return nil, fmt.Errorf("domain %q enables autocert but server.certs_dir is not set; GhostGate's ACME manager installs issued certificates there", d.Domain)
+			}
+		}
+		return nil, fmt.Errorf("server.certs_dir is not set")
+	}
+
+	store, err := certs.NewStore(cfg.Server.CertsDir, cfg.Server.DevMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize certificate store: %w", err)
+	}
+	s.mu.Lock()
+	s.certStore = store
+	s.acmeManager = acme.NewManager(store)
+	s.mu.Unlock()
+
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: store.GetCertificate,
+	}, nil
+}
+
+// Run starts the HTTP, HTTPS, and (if configured) admin listeners, and
+// blocks until ctx is cancelled or a listener fails fatally.
+func (s *Server) Run(ctx context.Context) error {
+	cfg := s.Config()
+
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-reloadChan:
+				s.reloadFromDisk()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.mu.RLock()
+	configPath, confDir, providers := s.configPath, s.confDir, s.providers
+	s.mu.RUnlock()
+
+	if len(providers) > 0 {
+		allProviders := append([]provider.Provider{file.New(configPath, confDir, cfg.ReloadOnChange)}, providers...)
+		pool := safe.NewPool(ctx)
+		w := watcher.New(func(dc *dynamic.Configuration) {
+			base := s.Config()
+			newCfg := *base
+			newCfg.Domains = dc.Domains
+			if err := config.Validate(&newCfg); err != nil {
+				log.Printf("[WARN] dynamic provider update produced an invalid configuration, keeping the current configuration: %v", err)
+				return
+			}
+			s.Reload(&newCfg)
+		}, allProviders...)
+		if err := w.Start(ctx, pool); err != nil {
+			return fmt.Errorf("failed to start configuration watcher: %w", err)
+		}
+	} else if cfg.ReloadOnChange && configPath != "" {
+		go watchFiles([]string{configPath}, s.reloadFromDisk)
+	}
+
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Server.AdminAPI != "" {
+		auth := cfg.Server.AdminAPIAuth
+		s.adminServer = &http.Server{Addr: cfg.Server.AdminAPI, Handler: newAdminAPI(s, auth)}
+		if auth.ClientCA != "" {
+			adminTLSConfig := tlsConfig.Clone()
+			clientCAs, err := loadClientCAs(auth.ClientCA)
+			if err != nil {
+				return fmt.Errorf("failed to load admin_api_auth.client_ca: %w", err)
+			}
+			adminTLSConfig.ClientCAs = clientCAs
+			adminTLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+			s.adminServer.TLSConfig = adminTLSConfig
+		}
+		go func() {
+			log.Printf("Starting admin API on %s", cfg.Server.AdminAPI)
+			var err error
+			if s.adminServer.TLSConfig != nil {
+				err = s.adminServer.ListenAndServeTLS("", "")
+			} else {
+				err = s.adminServer.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				log.Printf("Admin API failed: %v", err)
+			}
+		}()
+	}
+
+	port := cfg.Server.Port
+	if port == 0 {
+		port = 80
+	}
+
+	s.httpServer = &http.Server{Addr: ":80", Handler: s.buildHTTPRedirectMux(cfg)}
+	go func() {
+		log.Printf("Starting HTTP server on :80")
+		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("HTTP server failed: %v", err)
+		}
+	}()
+
+	// The ACME manager's background renewal loop can issue over HTTP-01
+	// immediately, so it only starts once the :80 listener above is up to
+	// answer that challenge.
+	s.mu.RLock()
+	acmeManager := s.acmeManager
+	s.mu.RUnlock()
+	acmeManager.Start(ctx, func() []config.DomainConfig { return s.Config().Domains })
+
+	mux, accessLoggers := s.buildMux(cfg)
+	s.mu.Lock()
+	oldAccessLoggers := s.accessLoggers
+	s.accessLoggers = accessLoggers
+	s.mu.Unlock()
+	for _, al := range oldAccessLoggers {
+		al.Close()
+	}
+
+	s.httpsServer = &http.Server{
+		Addr:      ":443",
+		Handler:   mux,
+		TLSConfig: tlsConfig,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("Starting HTTPS server on :443")
+		if err := s.httpsServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		s.shutdown()
+		return nil
+	case err := <-errCh:
+		s.shutdown()
+		return err
+	}
+}
+
+func (s *Server) shutdown() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+	if s.httpsServer != nil {
+		s.httpsServer.Close()
+	}
+	if s.adminServer != nil {
+		s.adminServer.Close()
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghostkellz/ghostgate/internal/config"
+)
+
+// TestReloadFromDiskRejectsInvalidConfig verifies that reloadFromDisk
+// validates the freshly loaded configuration before swapping it in,
+// keeping the server on its current (valid) configuration instead of
+// reloading into one that would panic Server.buildMux later (e.g. a
+// domain duplicated between the main file and a conf.d file).
+func TestReloadFromDiskRejectsInvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	confDir := filepath.Join(dir, "conf.d")
+	if err := os.Mkdir(confDir, 0o755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+
+	configPath := filepath.Join(dir, "ghostgate.conf")
+	writeFile(t, configPath, "server:\n  port: 443\ndomains:\n  - domain: example.com\n")
+
+	cfg, err := config.LoadWithConfDir(configPath, confDir)
+	if err != nil {
+		t.Fatalf("initial load: %v", err)
+	}
+
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv.SetConfigSource(configPath, confDir)
+
+	// A conf.d file redefining the same domain makes the merged
+	// configuration invalid (duplicate domain name).
+	writeFile(t, filepath.Join(confDir, "dup.conf"), "domains:\n  - domain: example.com\n")
+
+	srv.reloadFromDisk()
+
+	if got := srv.Config(); len(got.Domains) != 1 {
+		t.Fatalf("reloadFromDisk applied an invalid configuration: got %d domains, want the original 1", len(got.Domains))
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("WriteFile %s: %v", path, err)
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// serveStaticFilesWithCache serves staticDir with basic Cache-Control
+// headers and a minimal directory index.
+func serveStaticFilesWithCache(staticDir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filePath := filepath.Join(staticDir, r.URL.Path)
+		info, err := os.Stat(filePath)
+		if os.IsNotExist(err) {
+			http.Error(w, "404 - Not Found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "403 - Forbidden", http.StatusForbidden)
+			return
+		}
+		if info.IsDir() {
+			// Serve directory index
+			files, err := os.ReadDir(filePath)
+			if err != nil {
+				http.Error(w, "403 - Forbidden", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("<html><body><ul>"))
+			for _, file := range files {
+				w.Write([]byte("<li><a href=\"" + file.Name() + "\">" + file.Name() + "</a></li>"))
+			}
+			w.Write([]byte("</ul></body></html>"))
+			return
+		}
+		// Serve file with caching headers
+		mimeType := mime.TypeByExtension(filepath.Ext(filePath))
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", mimeType)
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		http.ServeFile(w, r, filePath)
+	})
+}
+
+// serveHealthCheck reports liveness.
+func serveHealthCheck() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+}
+
+// serveWelcomePage is shown when no domains are configured.
+func serveWelcomePage() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`
+			<html>
+			<head><title>Welcome to GhostGate</title></head>
+			<body>
+			<h1>Welcome to GhostGate</h1>
+			<p>If you see this page, the GhostGate server is running successfully.</p>
+			<p>Configure your server by editing <code>gate.conf</code> or adding files to <code>conf.d/</code>.</p>
+			</body>
+			</html>
+		`))
+	})
+}
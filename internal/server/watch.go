@@ -0,0 +1,32 @@
+package server
+
+import (
+	"log"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchFiles calls reloadFunc whenever one of paths is written to or
+// created.
+func watchFiles(paths []string, reloadFunc func()) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("[WARN] Could not start file watcher: %v", err)
+		return
+	}
+	defer watcher.Close()
+	for _, p := range paths {
+		watcher.Add(p)
+	}
+	for {
+		select {
+		case event := <-watcher.Events:
+			if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create {
+				log.Println("[INFO] Config or cert file changed, reloading...")
+				reloadFunc()
+			}
+		case err := <-watcher.Errors:
+			log.Printf("[WARN] File watcher error: %v", err)
+		}
+	}
+}
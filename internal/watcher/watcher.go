@@ -0,0 +1,108 @@
+// Package watcher merges the Messages published by one or more
+// providers into a single dynamic.Configuration, debouncing bursts of
+// updates so a flurry of provider changes collapses into one swap
+// instead of one per message.
+package watcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/ghostkellz/ghostgate/internal/config"
+	"github.com/ghostkellz/ghostgate/internal/dynamic"
+	"github.com/ghostkellz/ghostgate/internal/provider"
+	"github.com/ghostkellz/ghostgate/internal/safe"
+)
+
+// DefaultDebounce is how long the Watcher waits for more Messages to
+// arrive before applying a merged configuration.
+const DefaultDebounce = 500 * time.Millisecond
+
+// Watcher runs a set of providers and calls OnChange with the merged
+// result of their latest Messages once per debounce window in which any
+// Message arrived, whether or not that Message changed anything.
+type Watcher struct {
+	Providers []provider.Provider
+	Debounce  time.Duration
+	OnChange  func(*dynamic.Configuration)
+}
+
+// New builds a Watcher over providers. onChange is invoked with the
+// merged configuration after each debounce window; it must not block.
+func New(onChange func(*dynamic.Configuration), providers ...provider.Provider) *Watcher {
+	return &Watcher{Providers: providers, Debounce: DefaultDebounce, OnChange: onChange}
+}
+
+// Start launches every provider and the merge loop, both tied to pool.
+// It returns once all providers have completed their initial Provide
+// call; the merge loop keeps running in the background until pool is
+// stopped or ctx is cancelled.
+func (w *Watcher) Start(ctx context.Context, pool *safe.Pool) error {
+	messages := make(chan provider.Message)
+
+	pool.Go(func(ctx context.Context) {
+		byProvider := make(map[string]*dynamic.Configuration)
+		var order []string // first-seen provider order, for deterministic last-write-wins merging
+		timer := time.NewTimer(w.Debounce)
+		if !timer.Stop() {
+			<-timer.C
+		}
+
+		for {
+			select {
+			case msg := <-messages:
+				if _, ok := byProvider[msg.ProviderName]; !ok {
+					order = append(order, msg.ProviderName)
+				}
+				byProvider[msg.ProviderName] = msg.Configuration
+				timer.Reset(w.Debounce)
+			case <-timer.C:
+				w.OnChange(merge(byProvider, order))
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	// The merge loop above must already be reading from messages before
+	// we call Provide: every provider sends its initial Message
+	// synchronously, before returning, on this unbuffered channel.
+	for _, p := range w.Providers {
+		p := p
+		if err := p.Provide(messages, pool); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// merge combines every provider's domains into one configuration,
+// deduping by domain name: if two providers (e.g. a Docker-discovered
+// domain and the same domain already in the static YAML) contribute the
+// same domain, the provider later in order wins, matching config's own
+// "merged in alongside them, last-write-wins per domain" doc comment.
+// Without this, a duplicate domain reaches http.ServeMux.Handle via
+// Server.buildMux and panics the process.
+func merge(byProvider map[string]*dynamic.Configuration, order []string) *dynamic.Configuration {
+	domains := make(map[string]config.DomainConfig)
+	var domainOrder []string
+	for _, name := range order {
+		cfg := byProvider[name]
+		if cfg == nil {
+			continue
+		}
+		for _, d := range cfg.Domains {
+			if _, ok := domains[d.Domain]; !ok {
+				domainOrder = append(domainOrder, d.Domain)
+			}
+			domains[d.Domain] = d
+		}
+	}
+
+	merged := &dynamic.Configuration{}
+	for _, name := range domainOrder {
+		merged.Domains = append(merged.Domains, domains[name])
+	}
+	return merged
+}
@@ -0,0 +1,44 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/ghostkellz/ghostgate/internal/config"
+	"github.com/ghostkellz/ghostgate/internal/dynamic"
+)
+
+// TestMergeDedupesByDomainLastProviderWins verifies that a domain
+// contributed by more than one provider (e.g. a Docker-discovered domain
+// that's also in the static YAML) is kept exactly once, with the
+// provider later in order winning — not concatenated, which would reach
+// http.ServeMux.Handle as a duplicate pattern and panic the process.
+func TestMergeDedupesByDomainLastProviderWins(t *testing.T) {
+	byProvider := map[string]*dynamic.Configuration{
+		"file": {Domains: []config.DomainConfig{
+			{Domain: "shared.example.com", StaticDir: "/from-file"},
+			{Domain: "file-only.example.com"},
+		}},
+		"docker": {Domains: []config.DomainConfig{
+			{Domain: "shared.example.com", StaticDir: "/from-docker"},
+			{Domain: "docker-only.example.com"},
+		}},
+	}
+
+	merged := merge(byProvider, []string{"file", "docker"})
+
+	if len(merged.Domains) != 3 {
+		t.Fatalf("expected 3 distinct domains, got %d: %+v", len(merged.Domains), merged.Domains)
+	}
+
+	byName := make(map[string]config.DomainConfig, len(merged.Domains))
+	for _, d := range merged.Domains {
+		if _, dup := byName[d.Domain]; dup {
+			t.Fatalf("domain %q appears more than once in the merged configuration", d.Domain)
+		}
+		byName[d.Domain] = d
+	}
+
+	if got := byName["shared.example.com"].StaticDir; got != "/from-docker" {
+		t.Errorf("expected the later provider (docker) to win for shared.example.com, got StaticDir %q", got)
+	}
+}